@@ -0,0 +1,9 @@
+// Package openapi holds the OpenAPI 3 description of the REST API.
+package openapi
+
+import _ "embed"
+
+// Spec is the OpenAPI document in YAML.
+//
+//go:embed openapi.yaml
+var Spec []byte
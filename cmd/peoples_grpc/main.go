@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/grpc_config"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/presentation/grpc"
+)
+
+func main() {
+	var cfg grpc_config.Config
+	cfg = config.LoadConfig(cfg)
+	errChan, err := run(cfg)
+	if err != nil {
+		log.Fatalf("Couldn't run: %v", err)
+	}
+	if err := <-errChan; err != nil {
+		log.Fatalf("Error while running: %v", err)
+	}
+}
+
+func run(cfg grpc_config.Config) (<-chan error, error) {
+	server, err := grpc.NewServerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lis, err := server.Listen()
+	if err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+	)
+
+	grpcServ := server.GetGrpc()
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down...")
+
+		ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		defer func() {
+			redisCtxTimeout, c := context.WithTimeout(ctxTimeout, 5*time.Second)
+			err := server.Close(redisCtxTimeout)
+			if err != nil {
+				log.Fatalf("Couldn't close server: %v", err)
+			}
+			stop()
+			cancel()
+			c()
+			close(errChan)
+		}()
+
+		server.Shutdown()
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServ.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctxTimeout.Done():
+			grpcServ.Stop()
+			errChan <- ctxTimeout.Err()
+		}
+
+		log.Println("Gracefully shutting down")
+	}()
+
+	go func() {
+		log.Println("Starting server...")
+
+		if err := grpcServ.Serve(lis); err != nil {
+			errChan <- err
+		}
+
+	}()
+
+	return errChan, nil
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+const agifyKeyPrefix = "agify:"
+
+// AgifyStats counts the lookups of a CacheAgifyRepo since it was created.
+type AgifyStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"`
+}
+
+// CacheAgifyRepo keeps enrichment results by name. Names the providers
+// do not know are kept for unknownExp, so they are retried sooner than
+// the known ones.
+type CacheAgifyRepo struct {
+	Client     *redis.Client
+	exp        time.Duration
+	unknownExp time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func NewCacheAgifyRepo(client *redis.Client, exp, unknownExp time.Duration) *CacheAgifyRepo {
+	return &CacheAgifyRepo{Client: client, exp: exp, unknownExp: unknownExp}
+}
+
+// Get returns the cached result for name, found is false on a miss.
+func (c *CacheAgifyRepo) Get(ctx context.Context, name string) (info dto.AgifyInfo, found bool, err error) {
+	result, err := c.Client.Get(ctx, agifyKeyPrefix+name).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return dto.AgifyInfo{}, false, nil
+	}
+	if err != nil {
+		c.errors.Add(1)
+		return dto.AgifyInfo{}, false, err
+	}
+
+	if err := json.Unmarshal(result, &info); err != nil {
+		c.errors.Add(1)
+		return dto.AgifyInfo{}, false, err
+	}
+	c.hits.Add(1)
+	return info, true, nil
+}
+
+func (c *CacheAgifyRepo) Set(ctx context.Context, name string, info dto.AgifyInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	exp := c.exp
+	if !info.Known() {
+		exp = c.unknownExp
+	}
+	if err := c.Client.Set(ctx, agifyKeyPrefix+name, data, exp).Err(); err != nil {
+		c.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+func (c *CacheAgifyRepo) Stats() AgifyStats {
+	return AgifyStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis answers GET and SET from memory, remembering the TTL of every
+// key, so no server is needed.
+type fakeRedis struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func (f *fakeRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (f *fakeRedis) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		switch cmd := cmd.(type) {
+		case *redis.StringCmd:
+			v, ok := f.values[args[1].(string)]
+			if !ok {
+				cmd.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			cmd.SetVal(v)
+		case *redis.StatusCmd:
+			key := args[1].(string)
+			f.values[key] = string(args[2].([]byte))
+			// SET key value px|ex ttl
+			if len(args) == 5 {
+				unit := time.Second
+				if args[3] == "px" {
+					unit = time.Millisecond
+				}
+				f.ttls[key] = time.Duration(args[4].(int64)) * unit
+			}
+			cmd.SetVal("OK")
+		}
+		return nil
+	}
+}
+
+func (f *fakeRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func newFakeAgifyRepo() (*CacheAgifyRepo, *fakeRedis) {
+	fake := &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+	client := redis.NewClient(&redis.Options{Addr: "fake:6379"})
+	client.AddHook(fake)
+	return NewCacheAgifyRepo(client, 24*time.Hour, time.Hour), fake
+}
+
+func TestCacheAgifyRepoNegativeCaching(t *testing.T) {
+	repo, fake := newFakeAgifyRepo()
+	ctx := context.Background()
+
+	known := dto.AgifyInfo{Age: 42, Sex: dto.SexMale, Nation: "RU"}
+	if err := repo.Set(ctx, "ivan", known); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Set(ctx, "xyzzy", dto.AgifyInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown names expire sooner, so the providers are asked again.
+	if got := fake.ttls[agifyKeyPrefix+"ivan"]; got != 24*time.Hour {
+		t.Errorf("TTL of a known name = %v, want 24h", got)
+	}
+	if got := fake.ttls[agifyKeyPrefix+"xyzzy"]; got != time.Hour {
+		t.Errorf("TTL of an unknown name = %v, want 1h", got)
+	}
+
+	info, found, err := repo.Get(ctx, "xyzzy")
+	if err != nil || !found || info.Known() {
+		t.Errorf("Get(unknown) = %+v, %v, %v, want a found unknown name", info, found, err)
+	}
+	info, found, err = repo.Get(ctx, "ivan")
+	if err != nil || !found || info != known {
+		t.Errorf("Get(known) = %+v, %v, %v", info, found, err)
+	}
+	if _, found, err := repo.Get(ctx, "anna"); err != nil || found {
+		t.Errorf("Get(missing) found = %v, err = %v", found, err)
+	}
+
+	if got, want := repo.Stats(), (AgifyStats{Hits: 2, Misses: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
@@ -62,3 +62,18 @@ func (c *CachePeopleRepo) Delete(ctx context.Context, uuid uuid.UUID) error {
 	}
 	return nil
 }
+
+func (c *CachePeopleRepo) DeleteMany(ctx context.Context, uuids []uuid.UUID) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(uuids))
+	for i, id := range uuids {
+		keys[i] = id.String()
+	}
+	if err := c.Client.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	return nil
+}
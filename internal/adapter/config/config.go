@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/graph_config"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/grpc_config"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/kafka_config"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/rest_config"
 	"log"
@@ -11,7 +12,7 @@ import (
 )
 
 type IConfig interface {
-	graph_config.Config | kafka_config.Config | rest_config.Config
+	graph_config.Config | grpc_config.Config | kafka_config.Config | rest_config.Config
 }
 
 func LoadConfig[C IConfig](cfg C) C {
@@ -0,0 +1,26 @@
+package grpc_config
+
+import "time"
+
+type Config struct {
+	Env    string `env:"ENV"`
+	Db     DbConfig
+	Server ServerConfig
+	Redis  RedisConfig
+}
+
+type DbConfig struct {
+	Driver string `env:"DB_DRIVER"`
+	Url    string `env:"DB_URL"`
+}
+
+type ServerConfig struct {
+	Address string `env:"SERVER_ADDRESS"`
+}
+
+type RedisConfig struct {
+	Address  string        `env:"REDIS_ADDRESS"`
+	Password string        `env:"REDIS_PASSWORD"`
+	DB       int           `env:"REDIS_DB"`
+	Timeout  time.Duration `env:"REDIS_PING_TIMEOUT"`
+}
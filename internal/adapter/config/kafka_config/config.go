@@ -1,9 +1,14 @@
 package kafka_config
 
+import "time"
+
 type Config struct {
-	Env   string `env:"ENV"`
-	Kafka KafkaConfig
-	DB    DbConfig
+	Env     string `env:"ENV"`
+	Kafka   KafkaConfig
+	DB      DbConfig
+	Redis   RedisConfig
+	Enrich  EnrichConfig
+	Metrics MetricsConfig
 }
 
 type KafkaConfig struct {
@@ -12,9 +17,56 @@ type KafkaConfig struct {
 	ConsumerGroup string `env:"KAFKA_CONSUMER_GROUP"`
 	ProducerTopic string `env:"KAFKA_PRODUCER_TOPIC"`
 	Timeout       int    `env:"KAFKA_TIMEOUT"`
+	// Messages are enriched in batches of up to BatchSize, waiting at
+	// most BatchLinger for a batch to fill. The providers take up to 10
+	// names per request.
+	BatchSize   int           `env:"KAFKA_BATCH_SIZE" env-default:"10"`
+	BatchLinger time.Duration `env:"KAFKA_BATCH_LINGER" env-default:"200ms"`
 }
 
 type DbConfig struct {
 	Driver string `env:"DB_DRIVER"`
 	URL    string `env:"DB_URL"`
 }
+
+// RedisConfig locates the cache of enrichment results. Names the
+// providers do not know are kept for UnknownTTL.
+type RedisConfig struct {
+	Address       string        `env:"REDIS_ADDRESS"`
+	Password      string        `env:"REDIS_PASSWORD"`
+	DB            int           `env:"REDIS_DB"`
+	TTL           time.Duration `env:"REDIS_AGIFY_TTL" env-default:"24h"`
+	UnknownTTL    time.Duration `env:"REDIS_AGIFY_UNKNOWN_TTL" env-default:"1h"`
+	StatsInterval time.Duration `env:"REDIS_STATS_INTERVAL" env-default:"1m"`
+}
+
+// EnrichConfig locates the APIs telling the age, gender and nationality
+// of a name.
+type EnrichConfig struct {
+	AgeURL    string `env:"ENRICH_AGE_URL" env-default:"https://api.agify.io"`
+	GenderURL string `env:"ENRICH_GENDER_URL" env-default:"https://api.genderize.io"`
+	NationURL string `env:"ENRICH_NATION_URL" env-default:"https://api.nationalize.io"`
+	APIKey    string `env:"ENRICH_API_KEY"`
+	// Timeout bounds a single request, Deadline the whole enrichment of
+	// a batch of messages.
+	Timeout         time.Duration `env:"ENRICH_TIMEOUT" env-default:"5s"`
+	Deadline        time.Duration `env:"ENRICH_DEADLINE" env-default:"15s"`
+	MaxConnsPerHost int           `env:"ENRICH_MAX_CONNS_PER_HOST" env-default:"16"`
+	// Transient failures are retried with a jittered backoff doubling
+	// from RetryBaseDelay up to RetryMaxDelay. A provider failing
+	// BreakerFailures times in a row is left alone for BreakerCooldown,
+	// and consumption is paused meanwhile.
+	Retries         int           `env:"ENRICH_RETRIES" env-default:"3"`
+	RetryBaseDelay  time.Duration `env:"ENRICH_RETRY_BASE_DELAY" env-default:"200ms"`
+	RetryMaxDelay   time.Duration `env:"ENRICH_RETRY_MAX_DELAY" env-default:"5s"`
+	BreakerFailures int           `env:"ENRICH_BREAKER_FAILURES" env-default:"5"`
+	BreakerCooldown time.Duration `env:"ENRICH_BREAKER_COOLDOWN" env-default:"30s"`
+}
+
+// MetricsConfig locates the endpoint serving the expvar counters, the
+// enrichment cache stats among them, at /debug/vars. It is disabled
+// unless Address is set, since the page also exposes the command line and
+// memory stats of the process.
+type MetricsConfig struct {
+	Address string `env:"METRICS_ADDRESS"`
+}
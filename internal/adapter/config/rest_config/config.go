@@ -3,10 +3,13 @@ package rest_config
 import "time"
 
 type Config struct {
-	Env    string `env:"ENV"`
-	Db     DbConfig
-	Server ServerConfig
-	Redis  RedisConfig
+	Env     string `env:"ENV"`
+	Db      DbConfig
+	Server  ServerConfig
+	Redis   RedisConfig
+	Kafka   KafkaConfig
+	Outbox  OutboxConfig
+	Webhook WebhookConfig
 }
 
 type DbConfig struct {
@@ -18,6 +21,10 @@ type ServerConfig struct {
 	Address     string        `env:"SERVER_ADDRESS"`
 	Timeout     time.Duration `env:"SERVER_TIMEOUT"`
 	IdleTimeout time.Duration `env:"SERVER_IDLE_TIMEOUT"`
+	AdminToken  string        `env:"SERVER_ADMIN_TOKEN"`
+	// ValidateRequests rejects requests that do not conform to the OpenAPI
+	// spec.
+	ValidateRequests bool `env:"SERVER_VALIDATE_REQUESTS" env-default:"false"`
 }
 
 type RedisConfig struct {
@@ -26,3 +33,28 @@ type RedisConfig struct {
 	DB       int           `env:"REDIS_DB"`
 	Timeout  time.Duration `env:"REDIS_PING_TIMEOUT"`
 }
+
+type KafkaConfig struct {
+	Address     string `env:"KAFKA_ADDRESS"`
+	OutboxTopic string `env:"KAFKA_OUTBOX_TOPIC" env-default:"peoples.events"`
+}
+
+// OutboxConfig tunes the relay publishing people change events.
+type OutboxConfig struct {
+	Interval  time.Duration `env:"OUTBOX_INTERVAL" env-default:"1s"`
+	BatchSize int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	// Lease is how long the publishers get to accept a batch before
+	// another relay may claim it again.
+	Lease time.Duration `env:"OUTBOX_LEASE" env-default:"30s"`
+}
+
+// WebhookConfig tunes the delivery of events to webhooks.
+type WebhookConfig struct {
+	Interval     time.Duration `env:"WEBHOOK_INTERVAL" env-default:"1s"`
+	BatchSize    int           `env:"WEBHOOK_BATCH_SIZE" env-default:"50"`
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+	MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"10"`
+	BackoffBase  time.Duration `env:"WEBHOOK_BACKOFF_BASE" env-default:"5s"`
+	BackoffMax   time.Duration `env:"WEBHOOK_BACKOFF_MAX" env-default:"1h"`
+	DisableAfter int           `env:"WEBHOOK_DISABLE_AFTER" env-default:"20"`
+}
@@ -0,0 +1,279 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Batch applies the items with one multi-row statement per kind of
+// operation, in dto.BatchOps order.
+//
+// In atomic mode all statements share one transaction and any failed item
+// rolls the whole batch back; the remaining items get dto.ErrBatchAborted.
+// Otherwise every kind commits on its own, and a kind whose statement fails
+// is retried item by item so that only the offending items fail.
+func (p *DbPeopleRepo) Batch(ctx context.Context, items []dto.BatchItem, atomic bool) ([]dto.BatchResult, error) {
+	results := make([]dto.BatchResult, len(items))
+	for i, item := range items {
+		results[i].ID = item.ID
+	}
+
+	groups := make(map[dto.BatchOp][]int)
+	for i, item := range items {
+		groups[item.Op] = append(groups[item.Op], i)
+	}
+
+	if atomic {
+		return results, p.batchAtomic(ctx, items, groups, results)
+	}
+
+	for _, op := range dto.BatchOps {
+		idx := groups[op]
+		if len(idx) == 0 {
+			continue
+		}
+
+		err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+			return batchExec(ctx, tx, op, items, idx, results)
+		})
+		if err == nil {
+			continue
+		}
+		if len(idx) == 1 {
+			results[idx[0]].Err = err
+			continue
+		}
+
+		for _, i := range idx {
+			if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+				return batchExec(ctx, tx, op, items, []int{i}, results)
+			}); err != nil {
+				results[i].Err = err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (p *DbPeopleRepo) batchAtomic(ctx context.Context, items []dto.BatchItem, groups map[dto.BatchOp][]int, results []dto.BatchResult) error {
+	aborted := false
+	err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, op := range dto.BatchOps {
+			idx := groups[op]
+			if len(idx) == 0 {
+				continue
+			}
+			if err := batchExec(ctx, tx, op, items, idx, results); err != nil {
+				for _, i := range idx {
+					results[i].Err = err
+				}
+				aborted = true
+				return err
+			}
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				aborted = true
+				return dto.ErrBatchAborted
+			}
+		}
+		return nil
+	})
+	if !aborted {
+		return err
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = dto.ErrBatchAborted
+		}
+		results[i].People = nil
+	}
+	return nil
+}
+
+// batchExec runs the statement of one kind of operation for the items at
+// idx and records their results. Items whose non-zero version does not
+// match the locked row get dto.ErrVersionConflict and are left out, and
+// items the statement did not touch get sql.ErrNoRows. A returned error
+// means the statement itself failed.
+func batchExec(ctx context.Context, tx *sqlx.Tx, op dto.BatchOp, items []dto.BatchItem, idx []int, results []dto.BatchResult) error {
+	byID := make(map[uuid.UUID]int, len(idx))
+
+	var before map[uuid.UUID]*dto.People
+	if op != dto.BatchCreate {
+		for _, i := range idx {
+			byID[items[i].ID] = i
+		}
+
+		var err error
+		if before, err = lockPeoples(ctx, tx, byID); err != nil {
+			return err
+		}
+
+		apply := make([]int, 0, len(idx))
+		for _, i := range idx {
+			locked, ok := before[items[i].ID]
+			if ok && items[i].Version != 0 && locked.Version != items[i].Version {
+				results[i].Err = dto.ErrVersionConflict
+				continue
+			}
+			apply = append(apply, i)
+		}
+		if len(apply) == 0 {
+			return nil
+		}
+		idx = apply
+	}
+
+	var (
+		stmt   string
+		args   []any
+		action string
+	)
+
+	switch op {
+	case dto.BatchCreate:
+		peoples := make([]dto.People, len(idx))
+		for n, i := range idx {
+			peoples[n] = newPeople(uuid.New(), items[i].People)
+			byID[peoples[n].ID] = i
+		}
+		stmt, args = batchCreateQuery(peoples)
+		action = dto.HistoryCreate
+
+	case dto.BatchUpdate:
+		peoples := make([]dto.People, len(idx))
+		for n, i := range idx {
+			peoples[n] = newPeople(items[i].ID, items[i].People)
+		}
+		stmt, args = batchUpdateQuery(peoples)
+		action = dto.HistoryUpdate
+
+	case dto.BatchDelete:
+		ids := make([]string, len(idx))
+		for n, i := range idx {
+			ids[n] = items[i].ID.String()
+		}
+		stmt = `UPDATE peoples SET deleted=true, deleted_at=now(), updated_at=now(), version=version+1
+			WHERE id = ANY($1::uuid[])
+			RETURNING ` + peopleColumns
+		args = []any{pq.Array(ids)}
+		action = dto.HistoryDelete
+	}
+
+	var touched dto.Peoples
+	if err := tx.SelectContext(ctx, &touched, stmt, args...); err != nil {
+		return err
+	}
+
+	changes := make([]change, len(touched))
+	for n := range touched {
+		changes[n] = change{Before: before[touched[n].ID], After: &touched[n]}
+	}
+	if err := recordChanges(ctx, tx, action, changes...); err != nil {
+		return err
+	}
+
+	for _, i := range idx {
+		results[i].Err = sql.ErrNoRows
+	}
+	for n := range touched {
+		i := byID[touched[n].ID]
+		results[i] = dto.BatchResult{ID: touched[n].ID, People: &touched[n]}
+	}
+	return nil
+}
+
+// lockPeoples locks the existing rows among ids and returns them by id.
+func lockPeoples(ctx context.Context, tx *sqlx.Tx, ids map[uuid.UUID]int) (map[uuid.UUID]*dto.People, error) {
+	list := make([]string, 0, len(ids))
+	for id := range ids {
+		list = append(list, id.String())
+	}
+
+	var peoples dto.Peoples
+	if err := tx.SelectContext(ctx, &peoples,
+		`SELECT `+peopleColumns+` FROM peoples WHERE id = ANY($1::uuid[]) FOR UPDATE`,
+		pq.Array(list),
+	); err != nil {
+		return nil, err
+	}
+
+	locked := make(map[uuid.UUID]*dto.People, len(peoples))
+	for n := range peoples {
+		locked[peoples[n].ID] = &peoples[n]
+	}
+	return locked, nil
+}
+
+func newPeople(id uuid.UUID, people dto.CreatePeople) dto.People {
+	return dto.People{
+		ID:         id,
+		FirstName:  people.FirstName,
+		LastName:   people.LastName,
+		Patronymic: people.Patronymic,
+		Age:        people.Age,
+		Sex:        people.Sex,
+		Nation:     people.Nation,
+	}
+}
+
+func batchCreateQuery(peoples []dto.People) (string, []any) {
+	q := &query{}
+	rows := make([]string, len(peoples))
+	for i, people := range peoples {
+		rows[i] = "(" + strings.Join([]string{
+			q.arg(people.ID),
+			q.arg(people.FirstName),
+			q.arg(people.LastName),
+			q.arg(people.Patronymic),
+			q.arg(people.Age),
+			q.arg(people.Sex),
+			q.arg(people.Nation),
+		}, ", ") + ")"
+	}
+
+	return `INSERT INTO peoples (id, first_name, last_name, patronymic, age, sex, nation)
+		VALUES ` + strings.Join(rows, ", ") + `
+		RETURNING ` + peopleColumns, q.args
+}
+
+// batchUpdateQuery joins peoples with a VALUES list of the new rows. Its
+// columns are prefixed so that peopleColumns stays unambiguous in RETURNING.
+func batchUpdateQuery(peoples []dto.People) (string, []any) {
+	q := &query{}
+	rows := make([]string, len(peoples))
+	for i, people := range peoples {
+		rows[i] = "(" + strings.Join([]string{
+			q.arg(people.ID) + "::uuid",
+			q.arg(people.FirstName) + "::varchar",
+			q.arg(people.LastName) + "::varchar",
+			q.arg(people.Patronymic) + "::varchar",
+			q.arg(people.Age) + "::int",
+			q.arg(people.Sex) + "::sex_enum",
+			q.arg(people.Nation) + "::varchar",
+		}, ", ") + ")"
+	}
+
+	return `UPDATE peoples SET
+			first_name = v.v_first_name,
+			last_name = v.v_last_name,
+			patronymic = v.v_patronymic,
+			age = v.v_age,
+			sex = v.v_sex,
+			nation = v.v_nation,
+			version = version + 1,
+			updated_at = now()
+		FROM (VALUES ` + strings.Join(rows, ", ") + `)
+			AS v (v_id, v_first_name, v_last_name, v_patronymic, v_age, v_sex, v_nation)
+		WHERE id = v.v_id
+		RETURNING ` + peopleColumns, q.args
+}
@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// change is a before/after pair of one row touched by a mutation.
+type change struct {
+	Before *dto.People
+	After  *dto.People
+}
+
+func (c change) id() uuid.UUID {
+	if c.After != nil {
+		return c.After.ID
+	}
+	return c.Before.ID
+}
+
+// recordChanges writes the changes into peoples_history, peoples_versions
+// and the outbox within the transaction of the mutation, attributed to the
+// actor of ctx.
+func recordChanges(ctx context.Context, tx *sqlx.Tx, action string, changes ...change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	actor := dto.ActorFromContext(ctx)
+
+	q := &query{}
+	rows := make([]string, len(changes))
+	for i, c := range changes {
+		before, err := snapshot(c.Before)
+		if err != nil {
+			return err
+		}
+		after, err := snapshot(c.After)
+		if err != nil {
+			return err
+		}
+
+		rows[i] = "(" + strings.Join([]string{
+			q.arg(c.id()),
+			q.arg(action),
+			q.arg(before),
+			q.arg(after),
+			q.arg(actor),
+		}, ", ") + ")"
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO peoples_history (people_id, action, before, after, actor)
+			VALUES `+strings.Join(rows, ", "),
+		q.args...,
+	); err != nil {
+		return err
+	}
+
+	if err := recordVersions(ctx, tx, changes); err != nil {
+		return err
+	}
+
+	return recordEvents(ctx, tx, dto.EventType(action), actor, changes)
+}
+
+// recordVersions keeps peoples_versions in step with the changes: the
+// current version of every touched row is closed and the new state, if
+// any, becomes valid from the transaction time.
+func recordVersions(ctx context.Context, tx *sqlx.Tx, changes []change) error {
+	ids := make([]string, len(changes))
+	for i, c := range changes {
+		ids[i] = c.id().String()
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE peoples_versions SET valid_to=now()
+			WHERE people_id = ANY($1::uuid[]) AND valid_to IS NULL`,
+		pq.Array(ids),
+	); err != nil {
+		return err
+	}
+
+	stmt, args := insertVersions(changes)
+	if stmt == "" {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+// insertVersions builds the INSERT of the new states in changes, valid
+// from the transaction time. It returns an empty statement if every
+// change is a purge.
+func insertVersions(changes []change) (string, []any) {
+	q := &query{}
+	var rows []string
+	for _, c := range changes {
+		if c.After == nil {
+			continue
+		}
+		rows = append(rows, "("+strings.Join([]string{
+			q.arg(c.After.ID),
+			q.arg(c.After.FirstName),
+			q.arg(c.After.LastName),
+			nullable(q, c.After.Patronymic),
+			q.arg(c.After.Age),
+			nullable(q, c.After.Sex),
+			nullable(q, c.After.Nation),
+			q.arg(c.After.Deleted),
+			q.arg(c.After.Version),
+			q.arg(c.After.CreatedAt),
+			q.arg(c.After.UpdatedAt),
+			q.arg(c.After.DeletedAt),
+			"now()",
+		}, ", ")+")")
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	return `INSERT INTO peoples_versions
+			(people_id, first_name, last_name, patronymic, age, sex, nation, deleted, version,
+				created_at, updated_at, deleted_at, valid_from)
+			VALUES ` + strings.Join(rows, ", "), q.args
+}
+
+// nullable binds an empty string as NULL, mirroring how peopleColumns
+// coalesces NULL to "".
+func nullable(q *query, v string) string {
+	if v == "" {
+		return "NULL"
+	}
+	return q.arg(v)
+}
+
+func snapshot(people *dto.People) (any, error) {
+	if people == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(people)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+type historyRow struct {
+	ID        int64     `db:"id"`
+	PeopleID  uuid.UUID `db:"people_id"`
+	Action    string    `db:"action"`
+	Before    []byte    `db:"before"`
+	After     []byte    `db:"after"`
+	Actor     string    `db:"actor"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+// GetHistory returns the changes of a person, newest first.
+func (p *DbPeopleRepo) GetHistory(ctx context.Context, uuid uuid.UUID, limit, offset int) (*dto.HistoryPage, error) {
+	var rows []historyRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`SELECT id, people_id, action, before, after, actor, changed_at
+			FROM peoples_history
+			WHERE people_id=$1
+			ORDER BY id DESC
+			LIMIT $2 OFFSET $3`,
+		uuid,
+		limit,
+		offset,
+	); err != nil {
+		return nil, err
+	}
+
+	page := &dto.HistoryPage{Entries: make([]dto.HistoryEntry, len(rows))}
+	if err := p.DB.GetContext(ctx, &page.Total,
+		`SELECT count(*) FROM peoples_history WHERE people_id=$1`,
+		uuid,
+	); err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		entry := dto.HistoryEntry{
+			ID:        row.ID,
+			PeopleID:  row.PeopleID,
+			Action:    row.Action,
+			Actor:     row.Actor,
+			ChangedAt: row.ChangedAt,
+		}
+		if row.Before != nil {
+			if err := json.Unmarshal(row.Before, &entry.Before); err != nil {
+				return nil, err
+			}
+		}
+		if row.After != nil {
+			if err := json.Unmarshal(row.After, &entry.After); err != nil {
+				return nil, err
+			}
+		}
+		page.Entries[i] = entry
+	}
+
+	return page, nil
+}
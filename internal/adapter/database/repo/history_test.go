@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+func TestVersionsAsOf(t *testing.T) {
+	asOf := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	q := filterQuery(dto.Filter{AsOf: &asOf, FirstName: "Ivan"})
+
+	if !strings.Contains(q.from, "WHERE valid_from <= $1 AND (valid_to IS NULL OR valid_to > $1)) AS peoples") {
+		t.Errorf("from = %q, want the versions valid at $1", q.from)
+	}
+	if !strings.HasPrefix(q.from, "(SELECT people_id AS id,") {
+		t.Errorf("from = %q, want people_id selected as id", q.from)
+	}
+	// The filter applies to the derived table after the as_of argument.
+	if got, want := q.where(), " WHERE deleted = $2 AND first_name ILIKE $3"; got != want {
+		t.Errorf("where() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(q.args, []any{asOf, false, "Ivan"}) {
+		t.Errorf("args = %#v", q.args)
+	}
+}
+
+func TestInsertVersions(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ivan := &dto.People{
+		ID:        uuid.MustParse("6f1c3b2a-0000-4000-8000-000000000001"),
+		FirstName: "Ivan",
+		LastName:  "Petrov",
+		Age:       30,
+		Sex:       dto.SexMale,
+		Version:   2,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	purged := &dto.People{ID: uuid.MustParse("6f1c3b2a-0000-4000-8000-000000000002")}
+
+	stmt, args := insertVersions([]change{{Before: ivan, After: ivan}, {Before: purged}})
+
+	if !strings.HasPrefix(stmt, "INSERT INTO peoples_versions") {
+		t.Fatalf("stmt = %q, want an INSERT into peoples_versions", stmt)
+	}
+	// The purge has no new state; the empty patronymic and nation are NULL.
+	wantValues := "VALUES ($1, $2, $3, NULL, $4, $5, NULL, $6, $7, $8, $9, $10, now())"
+	if !strings.HasSuffix(stmt, wantValues) {
+		t.Errorf("stmt = %q, want it to end with %q", stmt, wantValues)
+	}
+
+	wantArgs := []any{ivan.ID, "Ivan", "Petrov", 30, dto.SexMale, false, 2, created, created, (*time.Time)(nil)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+
+	if stmt, _ := insertVersions([]change{{Before: purged}}); stmt != "" {
+		t.Errorf("got %q for purges only, want no statement", stmt)
+	}
+}
@@ -0,0 +1,201 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// outboxLock is the advisory lock key of the relay publishing the outbox.
+// Only one relay publishes at a time, so the events of a person leave in
+// the order they were written.
+const outboxLock = 0x6f7574626f78
+
+// recordEvents writes an outbox event per change within the transaction of
+// the mutation, so an event is published if and only if the change commits.
+func recordEvents(ctx context.Context, tx *sqlx.Tx, eventType, actor string, changes []change) error {
+	q := &query{}
+	rows := make([]string, len(changes))
+	for i, c := range changes {
+		people := c.After
+		if people == nil {
+			people = c.Before
+		}
+		payload, err := snapshot(people)
+		if err != nil {
+			return err
+		}
+
+		rows[i] = "(" + strings.Join([]string{
+			q.arg(c.id()),
+			q.arg(eventType),
+			q.arg(payload),
+			q.arg(actor),
+		}, ", ") + ")"
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO outbox (people_id, event_type, payload, actor)
+			VALUES `+strings.Join(rows, ", "),
+		q.args...,
+	)
+	return err
+}
+
+type outboxRow struct {
+	ID        int64     `db:"id"`
+	PeopleID  uuid.UUID `db:"people_id"`
+	EventType string    `db:"event_type"`
+	Payload   []byte    `db:"payload"`
+	Actor     string    `db:"actor"`
+	CreatedAt time.Time `db:"created_at"`
+	// PublishedSeq is the position of the event in the published stream
+	// and the ID clients see. IDs follow the insert order, which is not
+	// the commit order, so they cannot be used to resume a stream.
+	PublishedSeq *int64 `db:"published_seq"`
+}
+
+func (r outboxRow) event() (dto.Event, error) {
+	if r.PublishedSeq == nil {
+		return dto.Event{}, fmt.Errorf("outbox event %d has no sequence", r.ID)
+	}
+	event := dto.Event{
+		ID:         *r.PublishedSeq,
+		Type:       r.EventType,
+		PeopleID:   r.PeopleID,
+		Actor:      r.Actor,
+		OccurredAt: r.CreatedAt,
+	}
+	if err := json.Unmarshal(r.Payload, &event.People); err != nil {
+		return dto.Event{}, err
+	}
+	return event, nil
+}
+
+// ClaimEvents returns at most limit of the oldest unpublished events and
+// claims them for lease. The relay publishes them outside of any
+// transaction and then calls MarkPublished, or ReleaseEvents if that
+// failed. A relay that dies meanwhile hands its events over once the
+// lease expires, so delivery is at-least-once.
+//
+// Only one relay publishes at a time, so the events of a person leave in
+// the order they were written: nothing is claimed while the claim of
+// another relay is live.
+func (p *DbPeopleRepo) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]dto.Event, error) {
+	var rows []outboxRow
+
+	err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		var locked bool
+		if err := tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1)`, outboxLock); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var claimed bool
+		if err := tx.GetContext(ctx, &claimed,
+			`SELECT EXISTS (SELECT 1 FROM outbox WHERE published_at IS NULL AND claimed_until > now())`,
+		); err != nil {
+			return err
+		}
+		if claimed {
+			return nil
+		}
+
+		// Claimed events are numbered in ID order, which for the events of
+		// a person is the order they were written. A released event keeps
+		// its number, so a retry is deduplicated by its consumers.
+		return tx.SelectContext(ctx, &rows,
+			`WITH claimed AS (
+				SELECT id, published_seq FROM outbox
+					WHERE published_at IS NULL
+					ORDER BY id
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED
+			), numbered AS (
+				SELECT id, COALESCE(published_seq, nextval('outbox_publish_seq')) AS seq
+					FROM claimed
+					ORDER BY id
+			)
+			UPDATE outbox o
+				SET claimed_until = now() + $2::float8 * interval '1 millisecond', published_seq = n.seq
+				FROM numbered n
+				WHERE o.id = n.id
+				RETURNING o.id, o.people_id, o.event_type, o.payload, o.actor, o.created_at, o.published_seq`,
+			limit,
+			lease.Milliseconds(),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return *rows[i].PublishedSeq < *rows[j].PublishedSeq })
+
+	events := make([]dto.Event, len(rows))
+	for i, row := range rows {
+		event, err := row.event()
+		if err != nil {
+			return nil, err
+		}
+		events[i] = event
+	}
+	return events, nil
+}
+
+// MarkPublished marks claimed events published by their event IDs.
+func (p *DbPeopleRepo) MarkPublished(ctx context.Context, ids []int64) error {
+	_, err := p.DB.ExecContext(ctx,
+		`UPDATE outbox SET published_at=now(), claimed_until=NULL WHERE published_seq = ANY($1)`,
+		pq.Array(ids),
+	)
+	return err
+}
+
+// ReleaseEvents gives up the claim on events that could not be published,
+// so they are claimed again without waiting for the lease to expire.
+func (p *DbPeopleRepo) ReleaseEvents(ctx context.Context, ids []int64) error {
+	_, err := p.DB.ExecContext(ctx,
+		`UPDATE outbox SET claimed_until=NULL WHERE published_seq = ANY($1) AND published_at IS NULL`,
+		pq.Array(ids),
+	)
+	return err
+}
+
+// GetEventsAfter returns at most limit published events with an ID greater
+// than afterID, in the order they were published.
+func (p *DbPeopleRepo) GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]dto.Event, error) {
+	var rows []outboxRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`SELECT id, people_id, event_type, payload, actor, created_at, published_seq
+			FROM outbox
+			WHERE published_seq > $1 AND published_at IS NOT NULL
+			ORDER BY published_seq
+			LIMIT $2`,
+		afterID,
+		limit,
+	); err != nil {
+		return nil, err
+	}
+
+	events := make([]dto.Event, len(rows))
+	for i, row := range rows {
+		event, err := row.event()
+		if err != nil {
+			return nil, err
+		}
+		events[i] = event
+	}
+
+	return events, nil
+}
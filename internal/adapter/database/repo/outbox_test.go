@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestOutboxRowEvent(t *testing.T) {
+	seq := int64(42)
+	row := outboxRow{
+		ID:           7,
+		PeopleID:     uuid.MustParse("6f1c3b2a-0000-4000-8000-000000000001"),
+		EventType:    "people.created",
+		Payload:      []byte(`{"first_name":"Ivan"}`),
+		Actor:        "system",
+		PublishedSeq: &seq,
+	}
+
+	event, err := row.event()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clients resume after the publish sequence, not the insert ID.
+	if event.ID != 42 {
+		t.Errorf("ID = %d, want 42", event.ID)
+	}
+	if event.People == nil || event.People.FirstName != "Ivan" {
+		t.Errorf("People = %+v", event.People)
+	}
+
+	row.PublishedSeq = nil
+	if _, err := row.event(); err == nil {
+		t.Error("event() of an unnumbered row succeeded")
+	}
+}
@@ -1,7 +1,12 @@
 package repo
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
 	"github.com/google/uuid"
@@ -16,11 +21,11 @@ func NewDbPeopleRepo(conn *sqlx.DB) *DbPeopleRepo {
 	return &DbPeopleRepo{DB: conn}
 }
 
-func (p *DbPeopleRepo) GetByID(uuid uuid.UUID) (*dto.People, error) {
+func (p *DbPeopleRepo) GetByID(ctx context.Context, uuid uuid.UUID) (*dto.People, error) {
 	var people dto.People
 
-	if err := p.DB.Get(&people,
-		`SELECT * FROM peoples WHERE id=$1`,
+	if err := p.DB.GetContext(ctx, &people,
+		`SELECT `+peopleColumns+` FROM peoples WHERE id=$1`,
 		uuid,
 	); err != nil {
 		return nil, err
@@ -29,99 +34,328 @@ func (p *DbPeopleRepo) GetByID(uuid uuid.UUID) (*dto.People, error) {
 	return &people, nil
 }
 
-func (p *DbPeopleRepo) GetAllByFilter(filter dto.Filter) (*dto.Peoples, error) {
-	var peoples dto.Peoples
+// GetByIDAsOf returns a person as it was at the given time.
+func (p *DbPeopleRepo) GetByIDAsOf(ctx context.Context, uuid uuid.UUID, asOf time.Time) (*dto.People, error) {
+	var people dto.People
 
-	if err := p.DB.Select(&peoples,
-		`SELECT * FROM peoples WHERE deleted=$1 ORDER BY id LIMIT $2 OFFSET $3`,
-		filter.Deleted,
-		filter.Limit,
-		filter.Offset,
+	if err := p.DB.GetContext(ctx, &people,
+		`SELECT `+peopleColumns+` FROM `+versionsAsOf("$2")+` WHERE id=$1`,
+		uuid,
+		asOf,
 	); err != nil {
 		return nil, err
 	}
 
-	return &peoples, nil
+	return &people, nil
 }
 
-func (p *DbPeopleRepo) Create(people dto.CreatePeople) error {
-	tx, err := p.DB.Begin()
+func (p *DbPeopleRepo) GetAllByFilter(ctx context.Context, filter dto.Filter) (*dto.PeoplesPage, error) {
+	var peoples dto.Peoples
+
+	q := filterQuery(filter)
+	if filter.After != nil {
+		q.after(filter.Sort, *filter.After)
+	}
+
+	// One extra row tells whether there is a next page.
+	stmt := `SELECT ` + peopleColumns + ` FROM ` + q.from + q.where() + orderBy(filter.Sort) + ` LIMIT ` + q.arg(filter.Limit+1)
+	if filter.After == nil {
+		stmt += ` OFFSET ` + q.arg(filter.Offset)
+	}
+
+	if err := p.DB.SelectContext(ctx, &peoples, stmt, q.args...); err != nil {
+		return nil, err
+	}
+
+	total, err := p.CountByFilter(ctx, filter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, err := tx.Exec(
-		`INSERT INTO peoples (first_name, last_name, patronymic, age, sex, nation)
-			VALUES ($1, $2, $3, $4, $5, $6)`,
-		people.FirstName,
-		people.LastName,
-		people.Patronymic,
-		people.Age,
-		people.Sex,
-		people.Nation,
-	); err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Fatalf("[!Panic!] cannot rollback tx: %v\n", err)
+	page := &dto.PeoplesPage{Peoples: peoples, Total: total}
+	if len(peoples) > filter.Limit {
+		page.Peoples = peoples[:filter.Limit]
+		page.NextCursor = dto.NewCursor(page.Peoples[filter.Limit-1], filter.Sort, filter.AsOf)
+	}
+
+	return page, nil
+}
+
+// CountByFilter counts every row matching the filter, ignoring pagination.
+func (p *DbPeopleRepo) CountByFilter(ctx context.Context, filter dto.Filter) (int, error) {
+	var total int
+
+	q := filterQuery(filter)
+	if err := p.DB.GetContext(ctx, &total, `SELECT count(*) FROM `+q.from+q.where(), q.args...); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// searchThreshold is the minimal pg_trgm word similarity of a search hit.
+const searchThreshold = "0.3"
+
+func (p *DbPeopleRepo) Search(ctx context.Context, query string, limit int) (*dto.SearchHits, error) {
+	var hits dto.SearchHits
+
+	if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		// The threshold is applied through the indexable <% operator, so
+		// it is set for this transaction only.
+		if _, err := tx.ExecContext(ctx,
+			`SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`,
+			searchThreshold,
+		); err != nil {
+			return err
 		}
-		return err
+
+		return tx.SelectContext(ctx, &hits,
+			`SELECT `+peopleColumns+`, GREATEST(
+					word_similarity($1, first_name),
+					word_similarity($1, last_name),
+					word_similarity($1, COALESCE(patronymic, ''))
+				) AS score
+				FROM peoples
+				WHERE deleted=false AND ($1 <% first_name OR $1 <% last_name OR $1 <% patronymic)
+				ORDER BY score DESC, id
+				LIMIT $2`,
+			query,
+			limit,
+		)
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	return &hits, nil
+}
+
+func (p *DbPeopleRepo) Create(ctx context.Context, people dto.CreatePeople) (*dto.People, error) {
+	return p.create(ctx, people)
+}
+
+// CreateEnriched creates a person from a name enriched by the external
+// providers, publishing dto.EventEnriched after dto.EventCreated.
+func (p *DbPeopleRepo) CreateEnriched(ctx context.Context, people dto.CreatePeople) (*dto.People, error) {
+	return p.create(ctx, people, dto.EventEnriched)
+}
+
+func (p *DbPeopleRepo) create(ctx context.Context, people dto.CreatePeople, events ...string) (*dto.People, error) {
+	var created dto.People
+
+	if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &created,
+			`INSERT INTO peoples (first_name, last_name, patronymic, age, sex, nation)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING `+peopleColumns,
+			people.FirstName,
+			people.LastName,
+			people.Patronymic,
+			people.Age,
+			people.Sex,
+			people.Nation,
+		); err != nil {
+			return err
+		}
+
+		if err := recordChanges(ctx, tx, dto.HistoryCreate, change{After: &created}); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := recordEvents(ctx, tx, event, dto.ActorFromContext(ctx), []change{{After: &created}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &created, nil
 }
 
-func (p *DbPeopleRepo) Update(people dto.People) error {
-	tx, err := p.DB.Begin()
-	if err != nil {
-		return err
+// Update replaces a person. A non-zero people.Version must match the stored
+// version, otherwise dto.ErrVersionConflict is returned.
+func (p *DbPeopleRepo) Update(ctx context.Context, people dto.People) (*dto.People, error) {
+	var updated dto.People
+
+	if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPeople(ctx, tx, people.ID, people.Version)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.GetContext(ctx, &updated,
+			`UPDATE peoples 
+				SET first_name=$1, last_name=$2, patronymic=$3, age=$4, sex=$5, nation=$6,
+					version=version+1, updated_at=now()
+				WHERE id=$7
+				RETURNING `+peopleColumns,
+			people.FirstName,
+			people.LastName,
+			people.Patronymic,
+			people.Age,
+			people.Sex,
+			people.Nation,
+			people.ID,
+		); err != nil {
+			return err
+		}
+
+		return recordChanges(ctx, tx, dto.HistoryUpdate, change{Before: before, After: &updated})
+	}); err != nil {
+		return nil, err
 	}
 
-	if _, err := tx.Exec(
-		`UPDATE peoples 
-			SET first_name=$1, last_name=$2, patronymic=$3, age=$4, sex=$5, nation=$6
-			WHERE id=$7`,
-		people.FirstName,
-		people.LastName,
-		people.Patronymic,
-		people.Age,
-		people.Sex,
-		people.Nation,
-		people.ID,
-	); err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Fatalf("[!Panic!] cannot rollback tx: %v\n", err)
+	return &updated, nil
+}
+
+// Patch applies a merge patch to a person and returns the updated row.
+// A non-zero version must match the stored one.
+func (p *DbPeopleRepo) Patch(ctx context.Context, uuid uuid.UUID, patch dto.PatchPeople, version int) (*dto.People, error) {
+	var people dto.People
+
+	q := &query{}
+	var sets []string
+	sets = setField(q, sets, "first_name", patch.FirstName)
+	sets = setField(q, sets, "last_name", patch.LastName)
+	sets = setField(q, sets, "patronymic", patch.Patronymic)
+	sets = setField(q, sets, "age", patch.Age)
+	sets = setField(q, sets, "sex", patch.Sex)
+	sets = setField(q, sets, "nation", patch.Nation)
+	empty := len(sets) == 0
+	sets = append(sets, "version = version + 1", "updated_at = now()")
+
+	q.and("id = " + q.arg(uuid))
+
+	if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPeople(ctx, tx, uuid, version)
+		if err != nil {
+			return err
 		}
-		return err
+
+		if empty {
+			people = *before
+			return nil
+		}
+
+		if err := tx.GetContext(ctx, &people,
+			`UPDATE peoples SET `+strings.Join(sets, ", ")+q.where()+` RETURNING `+peopleColumns,
+			q.args...,
+		); err != nil {
+			return err
+		}
+
+		return recordChanges(ctx, tx, dto.HistoryUpdate, change{Before: before, After: &people})
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	return &people, nil
+}
+
+// DeleteByID soft-deletes a person. A non-zero version must match the
+// stored one.
+func (p *DbPeopleRepo) DeleteByID(ctx context.Context, uuid uuid.UUID, version int) error {
+	return p.inTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPeople(ctx, tx, uuid, version)
+		if err != nil {
+			return err
+		}
+
+		var deleted dto.People
+		if err := tx.GetContext(ctx, &deleted,
+			`UPDATE peoples SET deleted=$1, deleted_at=now(), updated_at=now(), version=version+1
+				WHERE id=$2
+				RETURNING `+peopleColumns,
+			true,
+			uuid,
+		); err != nil {
+			return err
+		}
+
+		return recordChanges(ctx, tx, dto.HistoryDelete, change{Before: before, After: &deleted})
+	})
+}
+
+// Restore undoes a soft delete and returns the restored row.
+func (p *DbPeopleRepo) Restore(ctx context.Context, uuid uuid.UUID) (*dto.People, error) {
+	var people dto.People
+
+	if err := p.inTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPeople(ctx, tx, uuid, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.GetContext(ctx, &people,
+			`UPDATE peoples SET deleted=false, deleted_at=NULL, updated_at=now(), version=version+1
+				WHERE id=$1
+				RETURNING `+peopleColumns,
+			uuid,
+		); err != nil {
+			return err
+		}
+
+		return recordChanges(ctx, tx, dto.HistoryRestore, change{Before: before, After: &people})
+	}); err != nil {
+		return nil, err
 	}
-	return nil
+
+	return &people, nil
+}
+
+// Purge removes the row permanently. A non-zero version must match the
+// stored one.
+func (p *DbPeopleRepo) Purge(ctx context.Context, uuid uuid.UUID, version int) error {
+	return p.inTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := lockPeople(ctx, tx, uuid, version)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM peoples WHERE id=$1`, uuid); err != nil {
+			return err
+		}
+
+		return recordChanges(ctx, tx, dto.HistoryPurge, change{Before: before})
+	})
 }
 
-func (p *DbPeopleRepo) DeleteByID(uuid uuid.UUID) error {
-	tx, err := p.DB.Begin()
+// lockPeople locks a row until the end of the transaction and returns it.
+// A non-zero version must match the stored one.
+func lockPeople(ctx context.Context, tx *sqlx.Tx, uuid uuid.UUID, version int) (*dto.People, error) {
+	var people dto.People
+
+	if err := tx.GetContext(ctx, &people,
+		`SELECT `+peopleColumns+` FROM peoples WHERE id=$1 FOR UPDATE`,
+		uuid,
+	); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && people.Version != version {
+		return nil, dto.ErrVersionConflict
+	}
+	return &people, nil
+}
+
+func (p *DbPeopleRepo) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return inTx(ctx, p.DB, fn)
+}
+
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	if _, err := tx.Exec(
-		`UPDATE peoples SET deleted=$1 WHERE id=$2`,
-		true,
-		uuid,
-	); err != nil {
-		if err := tx.Rollback(); err != nil {
+	if err := fn(tx); err != nil {
+		// A cancelled context has already rolled the transaction back.
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
 			log.Fatalf("[!Panic!] cannot rollback tx: %v\n", err)
 		}
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-	return nil
+	return tx.Commit()
 }
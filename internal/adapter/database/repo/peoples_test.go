@@ -1 +1,128 @@
 package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestFilterQuery(t *testing.T) {
+	ageMin, ageMax := 18, 30
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	q := filterQuery(dto.Filter{
+		FirstName:      "Ivan",
+		LastNamePrefix: "Iva_%",
+		AgeMin:         &ageMin,
+		AgeMax:         &ageMax,
+		Sex:            "male",
+		Nations:        []string{"RU", "UA"},
+		UpdatedSince:   &since,
+	})
+
+	wantWhere := " WHERE deleted = $1 AND first_name ILIKE $2 AND last_name ILIKE $3" +
+		" AND age >= $4 AND age <= $5 AND sex = $6 AND nation = ANY($7) AND updated_at >= $8"
+	if got := q.where(); got != wantWhere {
+		t.Errorf("where() = %q, want %q", got, wantWhere)
+	}
+
+	wantArgs := []any{false, "Ivan", `Iva\_\%%`, 18, 30, "male", pq.Array([]string{"RU", "UA"}), since}
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", q.args, wantArgs)
+	}
+}
+
+func TestFilterQueryExactName(t *testing.T) {
+	q := filterQuery(dto.Filter{FirstName: "Iv_n", Patronymic: "100%"})
+
+	want := " WHERE deleted = $1 AND first_name ILIKE $2 AND patronymic ILIKE $3"
+	if got := q.where(); got != want {
+		t.Errorf("where() = %q, want %q", got, want)
+	}
+
+	// Without a trailing %, ILIKE is a case-insensitive exact match.
+	wantArgs := []any{false, `Iv\_n`, `100\%`}
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", q.args, wantArgs)
+	}
+}
+
+func TestFilterQueryEmpty(t *testing.T) {
+	q := filterQuery(dto.Filter{Deleted: true})
+
+	if got, want := q.where(), " WHERE deleted = $1"; got != want {
+		t.Errorf("where() = %q, want %q", got, want)
+	}
+	if len(q.args) != 1 || q.args[0] != true {
+		t.Errorf("args = %#v, want [true]", q.args)
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	id := uuid.MustParse("6f1c3b2a-0000-4000-8000-000000000001")
+	sort := []dto.SortField{{Field: "last_name"}, {Field: "age", Desc: true}}
+
+	q := &query{}
+	q.after(sort, dto.Cursor{Values: []string{"Ivanov", "30"}, ID: id})
+
+	want := " WHERE ((last_name > $1) OR (last_name = $1 AND COALESCE(age, 0) < $2)" +
+		" OR (last_name = $1 AND COALESCE(age, 0) = $2 AND id > $3))"
+	if got := q.where(); got != want {
+		t.Errorf("where() = %q, want %q", got, want)
+	}
+
+	wantArgs := []any{"Ivanov", "30", id}
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", q.args, wantArgs)
+	}
+
+	if got, want := orderBy(sort), " ORDER BY last_name, COALESCE(age, 0) DESC, id"; got != want {
+		t.Errorf("orderBy() = %q, want %q", got, want)
+	}
+}
+
+func TestSetField(t *testing.T) {
+	patch := dto.PatchPeople{
+		LastName:   dto.Field[string]{Set: true, Value: "Petrov"},
+		Patronymic: dto.Field[string]{Set: true, Null: true},
+		Age:        dto.Field[int]{Set: true, Value: 42},
+	}
+
+	q := &query{}
+	var sets []string
+	sets = setField(q, sets, "first_name", patch.FirstName)
+	sets = setField(q, sets, "last_name", patch.LastName)
+	sets = setField(q, sets, "patronymic", patch.Patronymic)
+	sets = setField(q, sets, "age", patch.Age)
+
+	wantSets := []string{"last_name = $1", "patronymic = NULL", "age = $2"}
+	if !reflect.DeepEqual(sets, wantSets) {
+		t.Errorf("sets = %#v, want %#v", sets, wantSets)
+	}
+	if wantArgs := []any{"Petrov", 42}; !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", q.args, wantArgs)
+	}
+}
+
+func TestBatchUpdateQuery(t *testing.T) {
+	id := uuid.MustParse("6f1c3b2a-0000-4000-8000-000000000002")
+
+	stmt, args := batchUpdateQuery([]dto.People{
+		{ID: id, FirstName: "Ivan", LastName: "Ivanov", Age: 30, Sex: "male", Nation: "RU"},
+	})
+
+	wantRow := "($1::uuid, $2::varchar, $3::varchar, $4::varchar, $5::int, $6::sex_enum, $7::varchar)"
+	if !strings.Contains(stmt, wantRow) {
+		t.Errorf("statement %q does not contain %q", stmt, wantRow)
+	}
+
+	wantArgs := []any{id, "Ivan", "Ivanov", "", 30, "male", "RU"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
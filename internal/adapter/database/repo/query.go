@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/lib/pq"
+)
+
+// peopleColumns selects a peoples row. Nullable columns are coalesced so the
+// row always scans into dto.People.
+const peopleColumns = `id, first_name, last_name,
+	COALESCE(patronymic, '') AS patronymic,
+	COALESCE(age, 0) AS age,
+	COALESCE(sex::text, '') AS sex,
+	COALESCE(nation, '') AS nation,
+	deleted,
+	version,
+	created_at,
+	updated_at,
+	deleted_at`
+
+// peopleVersionColumns selects a peoples_versions row under the column
+// names of peoples.
+const peopleVersionColumns = `people_id AS id, first_name, last_name, patronymic,
+	age, sex, nation, deleted, version, created_at, updated_at, deleted_at`
+
+// versionsAsOf is a derived table holding the peoples rows as they were at
+// the time bound to the asOf placeholder.
+func versionsAsOf(asOf string) string {
+	return `(SELECT ` + peopleVersionColumns + ` FROM peoples_versions
+		WHERE valid_from <= ` + asOf + ` AND (valid_to IS NULL OR valid_to > ` + asOf + `)) AS peoples`
+}
+
+// query accumulates WHERE conditions together with their positional
+// arguments, so user input never ends up inside the SQL text.
+type query struct {
+	from  string
+	conds []string
+	args  []any
+}
+
+func (q *query) arg(v any) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *query) and(cond string) {
+	q.conds = append(q.conds, cond)
+}
+
+func (q *query) where() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conds, " AND ")
+}
+
+// sortColumns maps dto.SortableFields onto the SQL expressions they order
+// by. Nullable columns are coalesced so keyset comparisons never see NULL.
+var sortColumns = map[string]string{
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"patronymic": "COALESCE(patronymic, '')",
+	"age":        "COALESCE(age, 0)",
+	"sex":        "COALESCE(sex::text, '')",
+	"nation":     "COALESCE(nation, '')",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+func orderBy(sort []dto.SortField) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		if field.Desc {
+			parts = append(parts, sortColumns[field.Field]+" DESC")
+		} else {
+			parts = append(parts, sortColumns[field.Field])
+		}
+	}
+	parts = append(parts, "id")
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// after adds the keyset condition selecting rows that follow the cursor in
+// the given sort order, expanded as (a > x) OR (a = x AND b > y) ... so
+// that every column may have its own direction.
+func (q *query) after(sort []dto.SortField, cursor dto.Cursor) {
+	var (
+		ors []string
+		eqs []string
+	)
+	for i, field := range sort {
+		col := sortColumns[field.Field]
+		op := " > "
+		if field.Desc {
+			op = " < "
+		}
+		val := q.arg(cursor.Values[i])
+		ors = append(ors, "("+strings.Join(append(eqs, col+op+val), " AND ")+")")
+		eqs = append(eqs, col+" = "+val)
+	}
+	ors = append(ors, "("+strings.Join(append(eqs, "id > "+q.arg(cursor.ID)), " AND ")+")")
+
+	q.and("(" + strings.Join(ors, " OR ") + ")")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Names are matched case-insensitively, exactly or by prefix, with ILIKE
+// so the trigram indexes apply.
+func likePrefix(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
+
+func filterQuery(filter dto.Filter) *query {
+	q := &query{from: "peoples"}
+
+	if filter.AsOf != nil {
+		q.from = versionsAsOf(q.arg(*filter.AsOf))
+	}
+
+	if !filter.IncludeDeleted {
+		q.and("deleted = " + q.arg(filter.Deleted))
+	}
+
+	if filter.FirstName != "" {
+		q.and("first_name ILIKE " + q.arg(likeEscaper.Replace(filter.FirstName)))
+	}
+	if filter.FirstNamePrefix != "" {
+		q.and("first_name ILIKE " + q.arg(likePrefix(filter.FirstNamePrefix)))
+	}
+	if filter.LastName != "" {
+		q.and("last_name ILIKE " + q.arg(likeEscaper.Replace(filter.LastName)))
+	}
+	if filter.LastNamePrefix != "" {
+		q.and("last_name ILIKE " + q.arg(likePrefix(filter.LastNamePrefix)))
+	}
+	if filter.Patronymic != "" {
+		q.and("patronymic ILIKE " + q.arg(likeEscaper.Replace(filter.Patronymic)))
+	}
+	if filter.PatronymicPrefix != "" {
+		q.and("patronymic ILIKE " + q.arg(likePrefix(filter.PatronymicPrefix)))
+	}
+	if filter.AgeMin != nil {
+		q.and("age >= " + q.arg(*filter.AgeMin))
+	}
+	if filter.AgeMax != nil {
+		q.and("age <= " + q.arg(*filter.AgeMax))
+	}
+	if filter.Sex != "" {
+		q.and("sex = " + q.arg(filter.Sex))
+	}
+	if len(filter.Nations) > 0 {
+		q.and("nation = ANY(" + q.arg(pq.Array(filter.Nations)) + ")")
+	}
+	if filter.UpdatedSince != nil {
+		q.and("updated_at >= " + q.arg(*filter.UpdatedSince))
+	}
+	if filter.CreatedBefore != nil {
+		q.and("created_at < " + q.arg(*filter.CreatedBefore))
+	}
+
+	return q
+}
+
+// setField adds "column = value" to an UPDATE for a merge patch member that
+// is present, using NULL for an explicit null.
+func setField[T any](q *query, sets []string, column string, field dto.Field[T]) []string {
+	switch {
+	case !field.Set:
+		return sets
+	case field.Null:
+		return append(sets, column+" = NULL")
+	default:
+		return append(sets, column+" = "+q.arg(field.Value))
+	}
+}
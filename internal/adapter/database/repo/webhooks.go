@@ -0,0 +1,388 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, url, event_types, filter, secret, enabled, failures, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event, status, attempts,
+	COALESCE(last_status_code, 0) AS last_status_code,
+	COALESCE(last_error, '') AS last_error,
+	next_attempt_at, created_at, delivered_at`
+
+type DbWebhookRepo struct {
+	DB *sqlx.DB
+}
+
+func NewDbWebhookRepo(conn *sqlx.DB) *DbWebhookRepo {
+	return &DbWebhookRepo{DB: conn}
+}
+
+type webhookRow struct {
+	ID         uuid.UUID      `db:"id"`
+	URL        string         `db:"url"`
+	EventTypes pq.StringArray `db:"event_types"`
+	Filter     []byte         `db:"filter"`
+	Secret     string         `db:"secret"`
+	Enabled    bool           `db:"enabled"`
+	Failures   int            `db:"failures"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+func (r webhookRow) webhook() (dto.Webhook, error) {
+	hook := dto.Webhook{
+		ID:         r.ID,
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		Secret:     r.Secret,
+		Enabled:    r.Enabled,
+		Failures:   r.Failures,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+	if err := json.Unmarshal(r.Filter, &hook.Filter); err != nil {
+		return dto.Webhook{}, err
+	}
+	return hook, nil
+}
+
+func webhooks(rows []webhookRow) ([]dto.Webhook, error) {
+	hooks := make([]dto.Webhook, len(rows))
+	for i, row := range rows {
+		hook, err := row.webhook()
+		if err != nil {
+			return nil, err
+		}
+		hooks[i] = hook
+	}
+	return hooks, nil
+}
+
+func (p *DbWebhookRepo) GetByID(ctx context.Context, uuid uuid.UUID) (*dto.Webhook, error) {
+	var row webhookRow
+
+	if err := p.DB.GetContext(ctx, &row,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`,
+		uuid,
+	); err != nil {
+		return nil, err
+	}
+
+	hook, err := row.webhook()
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (p *DbWebhookRepo) GetAll(ctx context.Context, limit, offset int) (*dto.WebhooksPage, error) {
+	var rows []webhookRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at, id LIMIT $1 OFFSET $2`,
+		limit,
+		offset,
+	); err != nil {
+		return nil, err
+	}
+
+	hooks, err := webhooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.WebhooksPage{Webhooks: hooks}
+	if err := p.DB.GetContext(ctx, &page.Total, `SELECT count(*) FROM webhooks`); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetEnabled returns every webhook events are currently delivered to.
+func (p *DbWebhookRepo) GetEnabled(ctx context.Context) ([]dto.Webhook, error) {
+	var rows []webhookRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE enabled`,
+	); err != nil {
+		return nil, err
+	}
+
+	return webhooks(rows)
+}
+
+func (p *DbWebhookRepo) Create(ctx context.Context, hook dto.Webhook) (*dto.Webhook, error) {
+	filter, err := json.Marshal(hook.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var row webhookRow
+	if err := p.DB.GetContext(ctx, &row,
+		`INSERT INTO webhooks (url, event_types, filter, secret, enabled)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING `+webhookColumns,
+		hook.URL,
+		pq.StringArray(hook.EventTypes),
+		string(filter),
+		hook.Secret,
+		hook.Enabled,
+	); err != nil {
+		return nil, err
+	}
+
+	created, err := row.webhook()
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update replaces a webhook. Enabling a webhook resets its failure count.
+func (p *DbWebhookRepo) Update(ctx context.Context, hook dto.Webhook) (*dto.Webhook, error) {
+	filter, err := json.Marshal(hook.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var row webhookRow
+	if err := p.DB.GetContext(ctx, &row,
+		`UPDATE webhooks
+			SET url=$1, event_types=$2, filter=$3, secret=$4, enabled=$5,
+				failures=CASE WHEN $5 AND NOT enabled THEN 0 ELSE failures END,
+				updated_at=now()
+			WHERE id=$6
+			RETURNING `+webhookColumns,
+		hook.URL,
+		pq.StringArray(hook.EventTypes),
+		string(filter),
+		hook.Secret,
+		hook.Enabled,
+		hook.ID,
+	); err != nil {
+		return nil, err
+	}
+
+	updated, err := row.webhook()
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteByID removes a webhook together with its delivery log.
+func (p *DbWebhookRepo) DeleteByID(ctx context.Context, uuid uuid.UUID) error {
+	res, err := p.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE id=$1`, uuid)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Enqueue adds pending deliveries. An event already queued for a webhook
+// is skipped, as the outbox may hand the same event out again.
+func (p *DbWebhookRepo) Enqueue(ctx context.Context, deliveries []dto.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	q := &query{}
+	rows := make([]string, len(deliveries))
+	for i, d := range deliveries {
+		event, err := json.Marshal(d.Event)
+		if err != nil {
+			return err
+		}
+		rows[i] = "(" + strings.Join([]string{
+			q.arg(d.WebhookID),
+			q.arg(d.Event.ID),
+			q.arg(string(event)),
+		}, ", ") + ")"
+	}
+
+	_, err := p.DB.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event_id, event)
+			VALUES `+strings.Join(rows, ", ")+`
+			ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		q.args...,
+	)
+	return err
+}
+
+type deliveryRow struct {
+	ID             int64      `db:"id"`
+	WebhookID      uuid.UUID  `db:"webhook_id"`
+	Event          []byte     `db:"event"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	LastStatusCode int        `db:"last_status_code"`
+	LastError      string     `db:"last_error"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	URL            string     `db:"url"`
+	Secret         string     `db:"secret"`
+}
+
+func (r deliveryRow) delivery() (dto.WebhookDelivery, error) {
+	d := dto.WebhookDelivery{
+		ID:             r.ID,
+		WebhookID:      r.WebhookID,
+		Status:         r.Status,
+		Attempts:       r.Attempts,
+		LastStatusCode: r.LastStatusCode,
+		LastError:      r.LastError,
+		NextAttemptAt:  r.NextAttemptAt,
+		CreatedAt:      r.CreatedAt,
+		DeliveredAt:    r.DeliveredAt,
+		URL:            r.URL,
+		Secret:         r.Secret,
+	}
+	if err := json.Unmarshal(r.Event, &d.Event); err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+func deliveries(rows []deliveryRow) ([]dto.WebhookDelivery, error) {
+	list := make([]dto.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		d, err := row.delivery()
+		if err != nil {
+			return nil, err
+		}
+		list[i] = d
+	}
+	return list, nil
+}
+
+// ClaimDue returns at most limit pending deliveries of enabled webhooks
+// that are due, postponing them by lease. A worker that dies while
+// delivering thereby hands its deliveries over once the lease expires.
+func (p *DbWebhookRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error) {
+	var rows []deliveryRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`WITH claimed AS (
+				UPDATE webhook_deliveries SET next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+				WHERE id IN (
+					SELECT d.id FROM webhook_deliveries d
+						JOIN webhooks w ON w.id = d.webhook_id
+						WHERE d.status = 'pending' AND w.enabled AND d.next_attempt_at <= now()
+						ORDER BY d.next_attempt_at, d.id
+						LIMIT $1
+						FOR UPDATE OF d SKIP LOCKED
+				)
+				RETURNING `+deliveryColumns+`
+			)
+			SELECT claimed.*, w.url, w.secret
+				FROM claimed JOIN webhooks w ON w.id = claimed.webhook_id
+				ORDER BY claimed.id`,
+		limit,
+		lease.Milliseconds(),
+	); err != nil {
+		return nil, err
+	}
+
+	return deliveries(rows)
+}
+
+// RecordAttempt stores the outcome of a delivery attempt. A failed attempt
+// counts towards the consecutive failures of the webhook, which is
+// disabled once they reach disableAfter; a delivered one resets them. It
+// tells whether this attempt disabled the webhook.
+func (p *DbWebhookRepo) RecordAttempt(ctx context.Context, d dto.WebhookDelivery, disableAfter int) (bool, error) {
+	var disabled bool
+
+	err := inTx(ctx, p.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries
+				SET status=$1, attempts=$2, last_status_code=NULLIF($3, 0), last_error=NULLIF($4, ''),
+					next_attempt_at=$5, delivered_at=$6
+				WHERE id=$7`,
+			d.Status,
+			d.Attempts,
+			d.LastStatusCode,
+			d.LastError,
+			d.NextAttemptAt,
+			d.DeliveredAt,
+			d.ID,
+		); err != nil {
+			return err
+		}
+
+		if d.Status == dto.DeliveryDelivered {
+			_, err := tx.ExecContext(ctx, `UPDATE webhooks SET failures=0 WHERE id=$1 AND failures > 0`, d.WebhookID)
+			return err
+		}
+
+		err := tx.GetContext(ctx, &disabled,
+			`UPDATE webhooks w
+				SET failures=w.failures+1, enabled=w.enabled AND w.failures+1 < $1, updated_at=now()
+				FROM (SELECT id, enabled FROM webhooks WHERE id=$2 FOR UPDATE) old
+				WHERE w.id=old.id
+				RETURNING old.enabled AND NOT w.enabled`,
+			disableAfter,
+			d.WebhookID,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook was deleted meanwhile.
+			return nil
+		}
+		return err
+	})
+	return disabled, err
+}
+
+// GetDeliveries returns the delivery log of a webhook, newest first.
+func (p *DbWebhookRepo) GetDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset int) (*dto.WebhookDeliveriesPage, error) {
+	var rows []deliveryRow
+
+	if err := p.DB.SelectContext(ctx, &rows,
+		`SELECT `+deliveryColumns+`, '' AS url, '' AS secret
+			FROM webhook_deliveries
+			WHERE webhook_id=$1
+			ORDER BY id DESC
+			LIMIT $2 OFFSET $3`,
+		webhookID,
+		limit,
+		offset,
+	); err != nil {
+		return nil, err
+	}
+
+	list, err := deliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.WebhookDeliveriesPage{Deliveries: list}
+	if err := p.DB.GetContext(ctx, &page.Total,
+		`SELECT count(*) FROM webhook_deliveries WHERE webhook_id=$1`,
+		webhookID,
+	); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -0,0 +1,276 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaxBatch is how many names the providers accept in one request.
+const MaxBatch = 10
+
+// Names of the providers, used in errors and to keep a circuit breaker
+// each.
+const (
+	providerAge    = "agify"
+	providerGender = "genderize"
+	providerNation = "nationalize"
+)
+
+// Config locates the agify family of APIs. An empty APIKey uses the free
+// tier.
+//
+// A failed request is retried up to Retries times, after a jittered delay
+// doubling from RetryBaseDelay up to RetryMaxDelay, unless the provider
+// asked for a longer one. A provider failing BreakerFailures requests in
+// a row is not called for BreakerCooldown; zero BreakerFailures never
+// stops calling it.
+type Config struct {
+	AgeURL    string
+	GenderURL string
+	NationURL string
+	APIKey    string
+
+	Retries         int
+	RetryBaseDelay  time.Duration
+	RetryMaxDelay   time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+type agifyResponse struct {
+	Age int `json:"age"`
+}
+
+type genderizeResponse struct {
+	Gender string `json:"gender"`
+}
+
+type nationalizeResponse struct {
+	Country []country `json:"country"`
+}
+
+type country struct {
+	CountryID string `json:"country_id"`
+}
+
+// country returns the most probable country, or an empty one.
+func (r nationalizeResponse) country() string {
+	if len(r.Country) == 0 {
+		return ""
+	}
+	return r.Country[0].CountryID
+}
+
+// Agify enriches names through agify.io, genderize.io and nationalize.io,
+// or any API answering like them.
+type Agify struct {
+	client   *http.Client
+	cfg      Config
+	breakers map[string]*breaker
+}
+
+func NewAgify(client *http.Client, cfg Config) *Agify {
+	breakers := make(map[string]*breaker)
+	for _, provider := range []string{providerAge, providerGender, providerNation} {
+		breakers[provider] = newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown)
+	}
+
+	return &Agify{
+		client:   client,
+		cfg:      cfg,
+		breakers: breakers,
+	}
+}
+
+// OpenUntil returns when every provider may be called again, or zero if
+// none of their circuit breakers is open.
+func (a *Agify) OpenUntil() time.Time {
+	var until time.Time
+	for _, b := range a.breakers {
+		if u := b.until(); u.After(until) {
+			until = u
+		}
+	}
+	return until
+}
+
+// Ages returns the ages of names, in their order.
+func (a *Agify) Ages(ctx context.Context, names []string) ([]int, error) {
+	resp, err := getBatch[agifyResponse](ctx, a, providerAge, a.cfg.AgeURL, names)
+	if err != nil {
+		return nil, err
+	}
+	ages := make([]int, len(resp))
+	for i := range resp {
+		ages[i] = resp[i].Age
+	}
+	return ages, nil
+}
+
+// Genders returns the genders of names, in their order. The gender of a
+// name the provider does not know is empty.
+func (a *Agify) Genders(ctx context.Context, names []string) ([]string, error) {
+	resp, err := getBatch[genderizeResponse](ctx, a, providerGender, a.cfg.GenderURL, names)
+	if err != nil {
+		return nil, err
+	}
+	genders := make([]string, len(resp))
+	for i := range resp {
+		genders[i] = resp[i].Gender
+	}
+	return genders, nil
+}
+
+// Nationalities returns the most probable country of names, in their
+// order. It is empty for a name the provider does not know.
+func (a *Agify) Nationalities(ctx context.Context, names []string) ([]string, error) {
+	resp, err := getBatch[nationalizeResponse](ctx, a, providerNation, a.cfg.NationURL, names)
+	if err != nil {
+		return nil, err
+	}
+	nations := make([]string, len(resp))
+	for i := range resp {
+		nations[i] = resp[i].country()
+	}
+	return nations, nil
+}
+
+// getBatch asks provider about names with one request per MaxBatch of
+// them.
+func getBatch[T any](ctx context.Context, a *Agify, provider, baseURL string, names []string) ([]T, error) {
+	results := make([]T, 0, len(names))
+	for start := 0; start < len(names); start += MaxBatch {
+		chunk := names[start:min(start+MaxBatch, len(names))]
+
+		var resp []T
+		if err := a.get(ctx, provider, baseURL, url.Values{"name[]": chunk}, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp) != len(chunk) {
+			return nil, fmt.Errorf("%s: got %d results for %d names", provider, len(resp), len(chunk))
+		}
+		results = append(results, resp...)
+	}
+	return results, nil
+}
+
+// get asks provider with the names in params, retrying the transient
+// failures.
+func (a *Agify) get(ctx context.Context, provider, baseURL string, params url.Values, response any) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if a.cfg.APIKey != "" {
+		query.Set("apikey", a.cfg.APIKey)
+	}
+	u.RawQuery = query.Encode()
+
+	b := a.breakers[provider]
+	for attempt := 0; ; attempt++ {
+		if err := b.allow(time.Now()); err != nil {
+			return fmt.Errorf("%s: %w", provider, err)
+		}
+
+		err := a.do(ctx, provider, u.String(), response)
+		switch {
+		case err == nil:
+			b.success()
+			return nil
+		case ctx.Err() != nil:
+			b.release()
+			return err
+		case !Retryable(err):
+			// The provider answered, the request was wrong.
+			b.success()
+			return err
+		}
+
+		b.failure(time.Now())
+		if attempt >= a.cfg.Retries {
+			return err
+		}
+		if err := sleep(ctx, a.retryDelay(attempt, err)); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *Agify) do(ctx context.Context, provider, rawURL string, response any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Drain the error page so the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return &StatusError{
+			Provider:   provider,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("%s: failed to decode response %w", provider, err)
+	}
+	return nil
+}
+
+// retryDelay is a random delay up to the exponential backoff of attempt,
+// or the delay the provider asked for if it is longer.
+func (a *Agify) retryDelay(attempt int, err error) time.Duration {
+	backoff := a.cfg.RetryBaseDelay
+	for i := 0; i < attempt && backoff < a.cfg.RetryMaxDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > a.cfg.RetryMaxDelay {
+		backoff = a.cfg.RetryMaxDelay
+	}
+	var delay time.Duration
+	if backoff > 0 {
+		delay = time.Duration(rand.Int63n(int64(backoff) + 1))
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+	}
+	return delay
+}
+
+// sleep waits for d unless ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,209 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAgify(t *testing.T) {
+	var gotKeys []string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKeys = append(gotKeys, r.URL.Query().Get("apikey"))
+		if names := r.URL.Query()["name[]"]; len(names) != 1 || names[0] != "Ivan" {
+			t.Errorf("got names %q, want Ivan", names)
+		}
+
+		switch r.URL.Path {
+		case "/age":
+			w.Write([]byte(`[{"count":10,"name":"Ivan","age":42}]`))
+		case "/gender":
+			w.Write([]byte(`[{"count":10,"name":"Ivan","gender":"male","probability":0.99}]`))
+		case "/nation":
+			w.Write([]byte(`[{"count":10,"name":"Ivan","country":[{"country_id":"RU","probability":0.4},{"country_id":"UA","probability":0.2}]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer stub.Close()
+
+	agify := NewAgify(NewClient(time.Second, 4), Config{
+		AgeURL:    stub.URL + "/age",
+		GenderURL: stub.URL + "/gender",
+		NationURL: stub.URL + "/nation",
+		APIKey:    "key",
+	})
+	ctx := context.Background()
+	names := []string{"Ivan"}
+
+	ages, err := agify.Ages(ctx, names)
+	if err != nil || len(ages) != 1 || ages[0] != 42 {
+		t.Errorf("Ages() = %v, %v, want [42]", ages, err)
+	}
+	genders, err := agify.Genders(ctx, names)
+	if err != nil || len(genders) != 1 || genders[0] != "male" {
+		t.Errorf("Genders() = %q, %v, want [male]", genders, err)
+	}
+	nations, err := agify.Nationalities(ctx, names)
+	if err != nil || len(nations) != 1 || nations[0] != "RU" {
+		t.Errorf("Nationalities() = %q, %v, want [RU]", nations, err)
+	}
+
+	for _, key := range gotKeys {
+		if key != "key" {
+			t.Errorf("got apikey %q, want key", key)
+		}
+	}
+}
+
+func TestAgifyRetries(t *testing.T) {
+	var calls int
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"Request limit reached"}`))
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`[{"count":10,"name":"Ivan","age":42}]`))
+		}
+	}))
+	defer stub.Close()
+
+	agify := NewAgify(NewClient(time.Second, 4), Config{
+		AgeURL:         stub.URL,
+		Retries:        2,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  time.Millisecond,
+	})
+
+	ages, err := agify.Ages(context.Background(), []string{"Ivan"})
+	if err != nil || len(ages) != 1 || ages[0] != 42 {
+		t.Errorf("Ages() = %v, %v, want [42]", ages, err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
+
+func TestAgifyStatusError(t *testing.T) {
+	var calls int
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Invalid API key"}`))
+	}))
+	defer stub.Close()
+
+	agify := NewAgify(NewClient(time.Second, 4), Config{GenderURL: stub.URL, Retries: 3})
+
+	_, err := agify.Genders(context.Background(), []string{"Ivan"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %v, want a 401 *StatusError", err)
+	}
+	if Retryable(err) || calls != 1 {
+		t.Errorf("got %d calls of a non retryable error, want 1", calls)
+	}
+}
+
+func TestAgifyBreaker(t *testing.T) {
+	var calls int
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer stub.Close()
+
+	agify := NewAgify(NewClient(time.Second, 4), Config{
+		NationURL:       stub.URL,
+		BreakerFailures: 2,
+		BreakerCooldown: time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := agify.Nationalities(ctx, []string{"Ivan"}); !Retryable(err) {
+			t.Fatalf("got %v, want a retryable error", err)
+		}
+	}
+	if _, err := agify.Nationalities(ctx, []string{"Ivan"}); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("got %v, want ErrBreakerOpen", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+	if until := agify.OpenUntil(); !until.After(time.Now()) {
+		t.Errorf("OpenUntil() = %v, want a time in the future", until)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"Sun, 01 Oct 2023 12:00:30 GMT": 30 * time.Second,
+		"Sun, 01 Oct 2023 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestAgifyBatch(t *testing.T) {
+	var calls int
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		names := r.URL.Query()["name[]"]
+		if len(names) == 0 || len(names) > MaxBatch {
+			t.Errorf("got %d names in a request, want 1 to %d", len(names), MaxBatch)
+		}
+
+		resp := make([]genderizeResponse, len(names))
+		for i, name := range names {
+			if name != "Xyzzy" {
+				resp[i].Gender = "female"
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer stub.Close()
+
+	agify := NewAgify(NewClient(time.Second, 4), Config{GenderURL: stub.URL})
+
+	names := make([]string, MaxBatch+2)
+	for i := range names {
+		names[i] = fmt.Sprintf("Anna%d", i)
+	}
+	names[MaxBatch] = "Xyzzy"
+
+	genders, err := agify.Genders(context.Background(), names)
+	if err != nil {
+		t.Fatalf("Genders(): %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+	for i, gender := range genders {
+		want := "female"
+		if names[i] == "Xyzzy" {
+			want = ""
+		}
+		if gender != want {
+			t.Errorf("gender of %s = %q, want %q", names[i], gender, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package enrich
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker stops calling a provider for cooldown after failures failed
+// calls in a row. Once the cooldown is over a single call is let through
+// to probe the provider: it closes the breaker on success and opens it
+// again on failure.
+type breaker struct {
+	failures int
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	failed    int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(failures int, cooldown time.Duration) *breaker {
+	return &breaker{failures: failures, cooldown: cooldown}
+}
+
+// allow returns ErrBreakerOpen if the provider should not be called now.
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return ErrBreakerOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failed = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failed++
+	if b.probing || (b.failures > 0 && b.failed >= b.failures) {
+		b.openUntil = now.Add(b.cooldown)
+	}
+	b.probing = false
+}
+
+// until returns when the breaker lets a call through again, zero if it
+// is closed.
+func (b *breaker) until() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.openUntil
+}
+
+// release ends a call that tells nothing about the provider, such as one
+// canceled by the caller.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
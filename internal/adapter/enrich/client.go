@@ -0,0 +1,33 @@
+package enrich
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewClient returns the client shared by the lookups. Every lookup of a
+// message goes to one of a few hosts at once, so idle connections are
+// kept per host instead of being redialed.
+func NewClient(timeout time.Duration, maxConnsPerHost int) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
@@ -0,0 +1,72 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrBreakerOpen is returned without calling a provider that failed too
+// often lately.
+var ErrBreakerOpen = errors.New("circuit breaker open")
+
+// StatusError is a non-2xx response of a provider.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	// RetryAfter is how long the provider asked to wait, zero if it did
+	// not say.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Temporary reports whether the request may succeed if retried later.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// Retryable reports whether err is a failure of a provider rather than of
+// the request, so the same request may succeed later.
+func Retryable(err error) bool {
+	var statusErr *StatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrBreakerOpen):
+		return true
+	case errors.As(err, &statusErr):
+		return statusErr.Temporary()
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &netErr):
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or
+// as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,25 @@
+package actor
+
+import (
+	"net/http"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+const Header = "X-Actor"
+
+type Handler func(next http.Handler) http.Handler
+
+// New attributes the changes made by a request to the actor named in its
+// X-Actor header, recorded as claimed by the client.
+func New() Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			name := dto.ClaimedActor(r.Header.Get(Header))
+
+			next.ServeHTTP(w, r.WithContext(dto.WithActor(r.Context(), name)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
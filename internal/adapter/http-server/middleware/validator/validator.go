@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/rest"
+)
+
+// MergePatch is the media type of RFC 7396 patches, decoded as JSON.
+const MergePatch = "application/merge-patch+json"
+
+type Handler func(next http.Handler) http.Handler
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(MergePatch, openapi3filter.RegisteredBodyDecoder("application/json"))
+	// Accept what the handlers parse as an id.
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+		return err
+	})
+}
+
+// New rejects requests that do not conform to the operation of spec they
+// are routed to with 400 Bad Request. Requests for paths or methods the
+// spec does not describe are passed on, for the router to answer.
+func New(log *slog.Logger, spec *openapi3.T) (Handler, error) {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/validator"),
+		)
+		log.Info("Request validation enabled!")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				log.Info("request does not conform to the spec", logging.Err(err))
+				if err := render.Render(w, r, rest.ErrInvalidRequest(err)); err != nil {
+					log.Error("failed to render error", logging.Err(err))
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}, nil
+}
@@ -1,6 +1,10 @@
 package kafka
 
-import "github.com/confluentinc/confluent-kafka-go/kafka"
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
 
 type Consumer struct {
 	Consumer  *kafka.Consumer
@@ -32,7 +36,8 @@ type Producer struct {
 
 func NewKafkaProducer(host, topic string) (*Producer, error) {
 	cfg := kafka.ConfigMap{
-		"bootstrap.servers": host,
+		"bootstrap.servers":  host,
+		"enable.idempotence": true,
 	}
 	client, err := kafka.NewProducer(&cfg)
 	if err != nil {
@@ -43,3 +48,48 @@ func NewKafkaProducer(host, topic string) (*Producer, error) {
 		Topic:    topic,
 	}, nil
 }
+
+// Message is a record produced to the topic of a Producer.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// ProduceAndWait produces the messages and waits until the broker has
+// acknowledged every one of them. It returns the first delivery error.
+func (p *Producer) ProduceAndWait(ctx context.Context, msgs []Message) error {
+	deliveries := make(chan kafka.Event, len(msgs))
+
+	for _, msg := range msgs {
+		headers := make([]kafka.Header, 0, len(msg.Headers))
+		for key, value := range msg.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+
+		if err := p.Producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{
+				Topic:     &p.Topic,
+				Partition: kafka.PartitionAny,
+			},
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		}, deliveries); err != nil {
+			return err
+		}
+	}
+
+	for range msgs {
+		select {
+		case e := <-deliveries:
+			if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+				return m.TopicPartition.Error
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
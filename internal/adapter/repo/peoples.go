@@ -2,8 +2,11 @@ package repo
 
 import (
 	"context"
+	"log/slog"
+
 	cache "github.com/Dmitrij-Kochetov/peoples/internal/adapter/cache/repo"
 	db "github.com/Dmitrij-Kochetov/peoples/internal/adapter/database/repo"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -11,27 +14,50 @@ import (
 	"time"
 )
 
+// PeopleRepo reads people through the cache. The database is the source
+// of truth: once a write is committed, failing to update the cache is
+// logged and does not fail the write.
 type PeopleRepo struct {
-	db    *db.DbPeopleRepo
-	cache *cache.CachePeopleRepo
+	logger *slog.Logger
+	db     *db.DbPeopleRepo
+	cache  *cache.CachePeopleRepo
 }
 
-func NewPeopleRepo(dbConn *sqlx.DB, client *redis.Client, exp time.Duration) *PeopleRepo {
+func NewPeopleRepo(logger *slog.Logger, dbConn *sqlx.DB, client *redis.Client, exp time.Duration) *PeopleRepo {
 	return &PeopleRepo{
-		db:    db.NewDbPeopleRepo(dbConn),
-		cache: cache.NewCachePeopleRepo(client, exp),
+		logger: logger,
+		db:     db.NewDbPeopleRepo(dbConn),
+		cache:  cache.NewCachePeopleRepo(client, exp),
+	}
+}
+
+// cachePeople stores the committed state of people. If that fails the key
+// is dropped, so the previous state is not served.
+func (p *PeopleRepo) cachePeople(ctx context.Context, people dto.People) {
+	if err := p.cache.Update(ctx, people); err != nil {
+		p.logger.Warn("failed to cache people", logging.Err(err), slog.String("id", people.ID.String()))
+		p.uncache(ctx, people.ID)
+	}
+}
+
+// uncache drops the cached state of people changed in the database.
+func (p *PeopleRepo) uncache(ctx context.Context, uuids ...uuid.UUID) {
+	if err := p.cache.DeleteMany(ctx, uuids); err != nil {
+		p.logger.Error("failed to invalidate cached people", logging.Err(err), slog.Int("count", len(uuids)))
 	}
 }
 
 func (p *PeopleRepo) GetByID(ctx context.Context, uuid uuid.UUID) (*dto.People, error) {
 	res, err := p.cache.FindById(ctx, uuid)
-	if err == redis.Nil {
-		res, err := p.db.GetByID(uuid)
+	// Versions start at 1: an entry without one was cached before the
+	// version column existed, and its ETag would never match.
+	if err == redis.Nil || (err == nil && res.Version == 0) {
+		res, err := p.db.GetByID(ctx, uuid)
 		if err != nil {
 			return nil, err
 		}
 		if err := p.cache.Create(ctx, *res); err != nil {
-			return nil, err
+			p.logger.Warn("failed to cache people", logging.Err(err), slog.String("id", uuid.String()))
 		}
 		return res, nil
 	}
@@ -41,28 +67,103 @@ func (p *PeopleRepo) GetByID(ctx context.Context, uuid uuid.UUID) (*dto.People,
 	return res, nil
 }
 
-func (p *PeopleRepo) GetAllByFilter(ctx context.Context, filter dto.Filter) (*dto.Peoples, error) {
-	return p.db.GetAllByFilter(filter)
+// GetByIDAsOf reads the temporal store directly, as the cache only holds
+// the current state.
+func (p *PeopleRepo) GetByIDAsOf(ctx context.Context, uuid uuid.UUID, asOf time.Time) (*dto.People, error) {
+	return p.db.GetByIDAsOf(ctx, uuid, asOf)
+}
+
+func (p *PeopleRepo) GetAllByFilter(ctx context.Context, filter dto.Filter) (*dto.PeoplesPage, error) {
+	return p.db.GetAllByFilter(ctx, filter)
+}
+
+func (p *PeopleRepo) Search(ctx context.Context, query string, limit int) (*dto.SearchHits, error) {
+	return p.db.Search(ctx, query, limit)
+}
+
+func (p *PeopleRepo) Create(ctx context.Context, people dto.CreatePeople) (*dto.People, error) {
+	created, err := p.db.Create(ctx, people)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.cache.Create(ctx, *created); err != nil {
+		p.logger.Warn("failed to cache people", logging.Err(err), slog.String("id", created.ID.String()))
+	}
+	return created, nil
 }
 
-func (p *PeopleRepo) Create(ctx context.Context, people dto.CreatePeople) error {
-	return p.db.Create(people)
+func (p *PeopleRepo) Update(ctx context.Context, people dto.People) (*dto.People, error) {
+	updated, err := p.db.Update(ctx, people)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cachePeople(ctx, *updated)
+	return updated, nil
 }
 
-func (p *PeopleRepo) Update(ctx context.Context, people dto.People) error {
-	if err := p.db.Update(people); err != nil {
+func (p *PeopleRepo) Patch(ctx context.Context, uuid uuid.UUID, patch dto.PatchPeople, version int) (*dto.People, error) {
+	people, err := p.db.Patch(ctx, uuid, patch, version)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cachePeople(ctx, *people)
+	return people, nil
+}
+
+func (p *PeopleRepo) DeleteByID(ctx context.Context, uuid uuid.UUID, version int) error {
+	if err := p.db.DeleteByID(ctx, uuid, version); err != nil {
 		return err
 	}
 
-	return p.cache.Update(ctx, people)
+	p.uncache(ctx, uuid)
+	return nil
 }
 
-func (p *PeopleRepo) DeleteByID(ctx context.Context, uuid uuid.UUID) error {
-	if err := p.db.DeleteByID(uuid); err != nil {
-		return nil
+func (p *PeopleRepo) Restore(ctx context.Context, uuid uuid.UUID) (*dto.People, error) {
+	people, err := p.db.Restore(ctx, uuid)
+	if err != nil {
+		return nil, err
 	}
 
-	return p.cache.Delete(ctx, uuid)
+	p.cachePeople(ctx, *people)
+	return people, nil
+}
+
+func (p *PeopleRepo) Purge(ctx context.Context, uuid uuid.UUID, version int) error {
+	if err := p.db.Purge(ctx, uuid, version); err != nil {
+		return err
+	}
+
+	p.uncache(ctx, uuid)
+	return nil
+}
+
+func (p *PeopleRepo) Batch(ctx context.Context, items []dto.BatchItem, atomic bool) ([]dto.BatchResult, error) {
+	results, err := p.db.Batch(ctx, items, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	var touched []uuid.UUID
+	for _, result := range results {
+		if result.Err == nil {
+			touched = append(touched, result.ID)
+		}
+	}
+
+	p.uncache(ctx, touched...)
+	return results, nil
+}
+
+func (p *PeopleRepo) GetHistory(ctx context.Context, uuid uuid.UUID, limit, offset int) (*dto.HistoryPage, error) {
+	return p.db.GetHistory(ctx, uuid, limit, offset)
+}
+
+func (p *PeopleRepo) GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]dto.Event, error) {
+	return p.db.GetEventsAfter(ctx, afterID, limit)
 }
 
 func (p *PeopleRepo) Close(ctx context.Context) error {
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+// Channel is the Redis pub/sub channel the outbox relay fans events out on.
+const Channel = "peoples:events"
+
+// buffer is how many events a subscriber may fall behind before it is
+// dropped. Its client then reconnects and catches up from the outbox.
+const buffer = 64
+
+// Hub holds the single Redis subscription of a server and fans the events
+// out to its open streams.
+type Hub struct {
+	logger *slog.Logger
+	pubsub *redis.PubSub
+
+	mu     sync.Mutex
+	subs   map[chan dto.Event]struct{}
+	closed bool
+}
+
+func NewHub(logger *slog.Logger, client *redis.Client) *Hub {
+	return &Hub{
+		logger: logger,
+		pubsub: client.Subscribe(context.Background(), Channel),
+		subs:   make(map[chan dto.Event]struct{}),
+	}
+}
+
+// Run broadcasts the received events until Close is called.
+func (h *Hub) Run() {
+	go func() {
+		for msg := range h.pubsub.Channel() {
+			var event dto.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				h.logger.Error("failed to unmarshal event", logging.Err(err))
+				continue
+			}
+			h.broadcast(event)
+		}
+	}()
+}
+
+func (h *Hub) broadcast(event dto.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		select {
+		case sub <- event:
+		default:
+			delete(h.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every event from now on. It is
+// closed if the subscriber falls behind or the hub is closed.
+func (h *Hub) Subscribe() (<-chan dto.Event, func()) {
+	sub := make(chan dto.Event, buffer)
+
+	h.mu.Lock()
+	if h.closed {
+		close(sub)
+	} else {
+		h.subs[sub] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	return sub, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subs[sub]; ok {
+			delete(h.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// Close ends the subscription and closes every subscriber channel, so the
+// open streams finish.
+func (h *Hub) Close() error {
+	err := h.pubsub.Close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub)
+	}
+	h.closed = true
+
+	return err
+}
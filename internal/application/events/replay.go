@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// ReplayBatch is how many outbox events are read per query when a stream
+// resumes.
+const ReplayBatch = 500
+
+// Source reads the published events, as usecases.IPeopleRepo does.
+type Source interface {
+	GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]dto.Event, error)
+}
+
+// Replay passes the published events following afterID that match the
+// filter to emit, one page at a time in the order they were published, so
+// a long replay is never held in memory. It reads batch events per query
+// until a short page shows the outbox is exhausted, and returns the ID of
+// the last event read, or afterID if there was none. Live events up to
+// that ID have already been replayed.
+func Replay(ctx context.Context, src Source, afterID int64, filter dto.Filter, batch int, emit func([]dto.Event) error) (int64, error) {
+	for {
+		events, err := src.GetEventsAfter(ctx, afterID, batch)
+		if err != nil {
+			return afterID, err
+		}
+		matched := make([]dto.Event, 0, len(events))
+		for _, event := range events {
+			if filter.Matches(event.People) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) > 0 {
+			if err := emit(matched); err != nil {
+				return afterID, err
+			}
+		}
+		if len(events) > 0 {
+			afterID = events[len(events)-1].ID
+		}
+		if len(events) < batch {
+			return afterID, nil
+		}
+	}
+}
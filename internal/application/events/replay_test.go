@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// fakeSource serves published events with IDs 1..n, Ivan on odd IDs.
+type fakeSource struct {
+	n       int64
+	queries []int64
+	err     error
+}
+
+func (f *fakeSource) GetEventsAfter(_ context.Context, afterID int64, limit int) ([]dto.Event, error) {
+	f.queries = append(f.queries, afterID)
+	if f.err != nil {
+		return nil, f.err
+	}
+	var events []dto.Event
+	for id := afterID + 1; id <= f.n && len(events) < limit; id++ {
+		name := "Anna"
+		if id%2 == 1 {
+			name = "Ivan"
+		}
+		events = append(events, dto.Event{ID: id, People: &dto.People{FirstName: name}})
+	}
+	return events, nil
+}
+
+func ids(events []dto.Event) []int64 {
+	res := make([]int64, len(events))
+	for i, event := range events {
+		res[i] = event.ID
+	}
+	return res
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int64
+		afterID int64
+		pages   [][]int64
+		last    int64
+		queries []int64
+	}{
+		{"empty", 0, 0, nil, 0, []int64{0}},
+		{"short page", 2, 0, [][]int64{{1}}, 2, []int64{0}},
+		// A full page may be followed by more, and an exactly full last
+		// page by an empty one.
+		{"several pages", 7, 0, [][]int64{{1, 3}, {5}, {7}}, 7, []int64{0, 3, 6}},
+		{"full last page", 6, 0, [][]int64{{1, 3}, {5}}, 6, []int64{0, 3, 6}},
+		{"resumed", 7, 4, [][]int64{{5, 7}}, 7, []int64{4, 7}},
+		{"caught up", 7, 7, nil, 7, []int64{7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &fakeSource{n: tt.n}
+			var pages [][]int64
+			last, err := Replay(context.Background(), src, tt.afterID, dto.Filter{FirstName: "Ivan"}, 3, func(events []dto.Event) error {
+				pages = append(pages, ids(events))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pages, tt.pages) {
+				t.Errorf("Replay() emitted %v, want %v", pages, tt.pages)
+			}
+			if last != tt.last {
+				t.Errorf("Replay() = %d, want %d", last, tt.last)
+			}
+			if !reflect.DeepEqual(src.queries, tt.queries) {
+				t.Errorf("queried after %v, want %v", src.queries, tt.queries)
+			}
+		})
+	}
+}
+
+func TestReplayError(t *testing.T) {
+	src := &fakeSource{n: 5, err: errors.New("connection refused")}
+	emit := func([]dto.Event) error { return nil }
+	if _, err := Replay(context.Background(), src, 0, dto.Filter{}, 3, emit); !errors.Is(err, src.err) {
+		t.Errorf("Replay() error = %v, want %v", err, src.err)
+	}
+}
+
+func TestReplayEmitError(t *testing.T) {
+	src := &fakeSource{n: 7}
+	want := errors.New("broken pipe")
+	emit := func([]dto.Event) error { return want }
+	if _, err := Replay(context.Background(), src, 0, dto.Filter{}, 3, emit); !errors.Is(err, want) {
+		t.Errorf("Replay() error = %v, want %v", err, want)
+	}
+	if len(src.queries) != 1 {
+		t.Errorf("queried %d pages after the emit failed, want 1", len(src.queries))
+	}
+}
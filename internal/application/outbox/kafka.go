@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	internal "github.com/Dmitrij-Kochetov/peoples/internal/adapter/kafka"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// KafkaPublisher produces events to the topic of the producer, keyed by
+// person ID so that the events of a person stay in one partition.
+type KafkaPublisher struct {
+	producer *internal.Producer
+}
+
+func NewKafkaPublisher(producer *internal.Producer) *KafkaPublisher {
+	return &KafkaPublisher{producer: producer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, events []dto.Event) error {
+	msgs := make([]internal.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msgs[i] = internal.Message{
+			Key:     []byte(event.PeopleID.String()),
+			Value:   value,
+			Headers: map[string]string{"type": event.Type},
+		}
+	}
+
+	return p.producer.ProduceAndWait(ctx, msgs)
+}
@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/events"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPublisher fans events out to every subscriber of events.Channel.
+// Pub/sub does not keep messages, so subscribers that were away catch up
+// from the outbox.
+type RedisPublisher struct {
+	client *redis.Client
+}
+
+func NewRedisPublisher(client *redis.Client) *RedisPublisher {
+	return &RedisPublisher{client: client}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, batch []dto.Event) error {
+	pipe := p.client.Pipeline()
+	for _, event := range batch {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		pipe.Publish(ctx, events.Channel, data)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// Publisher delivers outbox events to some other system. Publish must not
+// return nil before every event has been accepted.
+type Publisher interface {
+	Publish(ctx context.Context, events []dto.Event) error
+}
+
+// Outbox holds the events to relay, as DbPeopleRepo does.
+type Outbox interface {
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]dto.Event, error)
+	MarkPublished(ctx context.Context, ids []int64) error
+	ReleaseEvents(ctx context.Context, ids []int64) error
+}
+
+// Relay moves events from the outbox to the publishers. Events are marked
+// published only after every publisher accepted them, so a publisher may
+// see an event more than once. No database transaction is open while the
+// publishers run.
+type Relay struct {
+	logger     *slog.Logger
+	outbox     Outbox
+	publishers []Publisher
+	interval   time.Duration
+	batchSize  int
+	lease      time.Duration
+	doneChan   chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewRelay creates a relay claiming batches of batchSize events every
+// interval. The publishers get lease to accept a batch before another
+// relay may claim it.
+func NewRelay(logger *slog.Logger, outbox Outbox, interval time.Duration, batchSize int, lease time.Duration, publishers ...Publisher) *Relay {
+	return &Relay{
+		logger:     logger,
+		outbox:     outbox,
+		publishers: publishers,
+		interval:   interval,
+		batchSize:  batchSize,
+		lease:      lease,
+		doneChan:   make(chan struct{}),
+		closeChan:  make(chan struct{}),
+	}
+}
+
+// Run relays events in the background until Shutdown is called. The outbox
+// is polled every interval and drained while full batches come back. A
+// batch being published when Shutdown is called is finished first.
+func (r *Relay) Run() {
+	go func() {
+		defer close(r.doneChan)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			for n := r.batchSize; n == r.batchSize; {
+				n = r.relay(context.Background())
+			}
+
+			select {
+			case <-ticker.C:
+			case <-r.closeChan:
+				r.logger.Info("outbox relay stopped")
+				return
+			}
+		}
+	}()
+}
+
+// relay publishes one batch of events and returns its size, zero if it
+// failed.
+func (r *Relay) relay(ctx context.Context) int {
+	events, err := r.outbox.ClaimEvents(ctx, r.batchSize, r.lease)
+	if err != nil {
+		r.logger.Error("failed to claim outbox events", logging.Err(err))
+		return 0
+	}
+	if len(events) == 0 {
+		return 0
+	}
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, r.lease)
+	err = r.publish(publishCtx, events)
+	cancel()
+	if err != nil {
+		r.logger.Error("failed to publish outbox events", logging.Err(err))
+		if err := r.outbox.ReleaseEvents(ctx, ids); err != nil {
+			r.logger.Error("failed to release outbox events", logging.Err(err))
+		}
+		return 0
+	}
+
+	if err := r.outbox.MarkPublished(ctx, ids); err != nil {
+		r.logger.Error("failed to mark outbox events published", logging.Err(err))
+		return 0
+	}
+	return len(events)
+}
+
+func (r *Relay) publish(ctx context.Context, events []dto.Event) error {
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Relay) Shutdown(ctx context.Context) error {
+	close(r.closeChan)
+
+	select {
+	case <-r.doneChan:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("context canceled: %w", ctx.Err())
+	}
+}
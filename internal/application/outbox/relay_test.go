@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// fakeOutbox hands out its pending events in claims of at most limit.
+type fakeOutbox struct {
+	pending   []dto.Event
+	claimed   []int64
+	published []int64
+	released  []int64
+	lease     time.Duration
+}
+
+func (o *fakeOutbox) ClaimEvents(_ context.Context, limit int, lease time.Duration) ([]dto.Event, error) {
+	o.lease = lease
+	n := min(limit, len(o.pending))
+	events := o.pending[:n]
+	o.pending = o.pending[n:]
+	for _, event := range events {
+		o.claimed = append(o.claimed, event.ID)
+	}
+	return events, nil
+}
+
+func (o *fakeOutbox) MarkPublished(_ context.Context, ids []int64) error {
+	o.published = append(o.published, ids...)
+	return nil
+}
+
+func (o *fakeOutbox) ReleaseEvents(_ context.Context, ids []int64) error {
+	o.released = append(o.released, ids...)
+	return nil
+}
+
+type fakePublisher struct {
+	got []int64
+	err error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, events []dto.Event) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("publish without a deadline")
+	}
+	if p.err != nil {
+		return p.err
+	}
+	for _, event := range events {
+		p.got = append(p.got, event.ID)
+	}
+	return nil
+}
+
+func eventsWithIDs(ids ...int64) []dto.Event {
+	list := make([]dto.Event, len(ids))
+	for i, id := range ids {
+		list[i] = dto.Event{ID: id}
+	}
+	return list
+}
+
+func TestRelayPublishes(t *testing.T) {
+	outbox := &fakeOutbox{pending: eventsWithIDs(1, 2, 3)}
+	first, second := &fakePublisher{}, &fakePublisher{}
+	relay := NewRelay(slog.New(slog.NewTextHandler(io.Discard, nil)), outbox, time.Second, 2, time.Minute, first, second)
+
+	if n := relay.relay(context.Background()); n != 2 {
+		t.Fatalf("relay() = %d, want a full batch of 2", n)
+	}
+	if n := relay.relay(context.Background()); n != 1 {
+		t.Fatalf("relay() = %d, want the last event", n)
+	}
+	if n := relay.relay(context.Background()); n != 0 {
+		t.Fatalf("relay() = %d on an empty outbox, want 0", n)
+	}
+
+	want := []int64{1, 2, 3}
+	if !reflect.DeepEqual(first.got, want) || !reflect.DeepEqual(second.got, want) {
+		t.Errorf("publishers got %v and %v, want %v", first.got, second.got, want)
+	}
+	if !reflect.DeepEqual(outbox.published, want) || outbox.released != nil {
+		t.Errorf("published %v, released %v; want %v published", outbox.published, outbox.released, want)
+	}
+	if outbox.lease != time.Minute {
+		t.Errorf("claimed for %v, want the lease", outbox.lease)
+	}
+}
+
+func TestRelayReleasesOnFailure(t *testing.T) {
+	outbox := &fakeOutbox{pending: eventsWithIDs(1, 2)}
+	failing := &fakePublisher{err: errors.New("broker down")}
+	last := &fakePublisher{}
+	relay := NewRelay(slog.New(slog.NewTextHandler(io.Discard, nil)), outbox, time.Second, 10, time.Minute, failing, last)
+
+	if n := relay.relay(context.Background()); n != 0 {
+		t.Fatalf("relay() = %d, want 0 after a failure", n)
+	}
+	if outbox.published != nil {
+		t.Errorf("published %v after a failure", outbox.published)
+	}
+	if !reflect.DeepEqual(outbox.released, []int64{1, 2}) {
+		t.Errorf("released %v, want [1 2]", outbox.released)
+	}
+	if last.got != nil {
+		t.Errorf("a later publisher got %v after a failure", last.got)
+	}
+}
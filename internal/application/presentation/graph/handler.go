@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/graphql-go/graphql"
+)
+
+// maxBodySize bounds the size of a GraphQL request.
+const maxBodySize = 1 << 20
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		http.Error(w, "request body must be a JSON GraphQL request", http.StatusBadRequest)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        r.Context(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.logger.Error("failed to write response", logging.Err(err))
+	}
+}
+
+func (s *Server) serveGraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(graphiQL)); err != nil {
+		s.logger.Error("failed to write response", logging.Err(err))
+	}
+}
+
+// graphiQL is the GraphiQL IDE, posting to the endpoint it is served from.
+const graphiQL = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Peoples GraphiQL</title>
+  <style>body { height: 100%; margin: 0; width: 100%; overflow: hidden; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById('graphiql'))
+      .render(React.createElement(GraphiQL, { fetcher: fetcher }));
+  </script>
+</body>
+</html>
+`
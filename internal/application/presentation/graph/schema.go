@@ -0,0 +1,383 @@
+package graph
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 1000
+)
+
+var (
+	errNotFound        = errors.New("person not found")
+	errVersionConflict = errors.New("version conflict")
+	errInternal        = errors.New("internal server error")
+)
+
+// peopleField resolves a field of the People type from a *dto.People.
+func peopleField(typ graphql.Output, get func(p *dto.People) any) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return get(p.Source.(*dto.People)), nil
+		},
+	}
+}
+
+var peopleType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "People",
+	Fields: graphql.Fields{
+		"id":         peopleField(graphql.NewNonNull(graphql.ID), func(p *dto.People) any { return p.ID.String() }),
+		"firstName":  peopleField(graphql.NewNonNull(graphql.String), func(p *dto.People) any { return p.FirstName }),
+		"lastName":   peopleField(graphql.NewNonNull(graphql.String), func(p *dto.People) any { return p.LastName }),
+		"patronymic": peopleField(graphql.String, func(p *dto.People) any { return p.Patronymic }),
+		"age":        peopleField(graphql.Int, func(p *dto.People) any { return p.Age }),
+		"sex":        peopleField(graphql.String, func(p *dto.People) any { return p.Sex }),
+		"nation":     peopleField(graphql.String, func(p *dto.People) any { return p.Nation }),
+		"deleted":    peopleField(graphql.NewNonNull(graphql.Boolean), func(p *dto.People) any { return p.Deleted }),
+		"version":    peopleField(graphql.NewNonNull(graphql.Int), func(p *dto.People) any { return p.Version }),
+		"createdAt":  peopleField(graphql.NewNonNull(graphql.DateTime), func(p *dto.People) any { return p.CreatedAt }),
+		"updatedAt":  peopleField(graphql.NewNonNull(graphql.DateTime), func(p *dto.People) any { return p.UpdatedAt }),
+		"deletedAt":  peopleField(graphql.DateTime, func(p *dto.People) any { return p.DeletedAt }),
+	},
+})
+
+var peoplesPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PeoplesPage",
+	Fields: graphql.Fields{
+		"items": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(peopleType))),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				page := p.Source.(*dto.PeoplesPage)
+				items := make([]*dto.People, len(page.Peoples))
+				for i := range page.Peoples {
+					items[i] = &page.Peoples[i]
+				}
+				return items, nil
+			},
+		},
+		"total": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(*dto.PeoplesPage).Total, nil
+			},
+		},
+		"nextCursor": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Pass as pagination.after to get the next page; null on the last page.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				page := p.Source.(*dto.PeoplesPage)
+				if page.NextCursor == nil {
+					return nil, nil
+				}
+				return page.NextCursor.Encode(), nil
+			},
+		},
+	},
+})
+
+var peopleFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PeopleFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"firstName":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"firstNamePrefix":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"lastName":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"lastNamePrefix":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"patronymic":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"patronymicPrefix": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"ageMin":           &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"ageMax":           &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"sex":              &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"nations":          &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"deleted":          &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"includeDeleted":   &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"updatedSince":     &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"createdBefore":    &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+var paginationInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "Pagination",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"limit":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+		"offset": &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+		"after":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"sort": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: `Comma separated fields, "-" for descending, e.g. "last_name,-age".`,
+		},
+	},
+})
+
+var peopleInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PeopleInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"firstName":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"lastName":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"patronymic": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"age":        &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"sex":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"nation":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+	},
+})
+
+// repoError hides repository errors other than the expected ones.
+func repoError(logger *slog.Logger, err error) error {
+	var invalid *dto.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return errNotFound
+	case errors.Is(err, dto.ErrVersionConflict):
+		return errVersionConflict
+	default:
+		logger.Error("internal server error", logging.Err(err))
+		return errInternal
+	}
+}
+
+// newSchema builds the schema, resolving through the usecases with repo.
+func newSchema(logger *slog.Logger, repo usecases.IPeopleRepo) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"person": &graphql.Field{
+				Type: peopleType,
+				Args: graphql.FieldConfigArgument{
+					"id":             &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"includeDeleted": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := idArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					people, err := usecases.GetPeopleByID(p.Context, repo, id, p.Args["includeDeleted"].(bool))
+					if errors.Is(err, sql.ErrNoRows) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, repoError(logger, err)
+					}
+					return people, nil
+				},
+			},
+			"peoples": &graphql.Field{
+				Type: graphql.NewNonNull(peoplesPageType),
+				Args: graphql.FieldConfigArgument{
+					"filter":     &graphql.ArgumentConfig{Type: peopleFilterInput},
+					"pagination": &graphql.ArgumentConfig{Type: paginationInput},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					filter, err := filterArgs(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					page, err := usecases.GetAllPeopleByFilter(p.Context, repo, filter)
+					if err != nil {
+						return nil, repoError(logger, err)
+					}
+					return page, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPeople": &graphql.Field{
+				Type: graphql.NewNonNull(peopleType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(peopleInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					created, err := usecases.CreatePeople(p.Context, repo, peopleArg(p.Args))
+					if err != nil {
+						return nil, repoError(logger, err)
+					}
+					return created, nil
+				},
+			},
+			"updatePeople": &graphql.Field{
+				Type: graphql.NewNonNull(peopleType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(peopleInput)},
+					"version": &graphql.ArgumentConfig{
+						Type:        graphql.Int,
+						Description: "The version the update is based on; fails on a mismatch.",
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := idArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					data := peopleArg(p.Args)
+					people, err := usecases.UpdatePeopleByID(p.Context, repo, dto.People{
+						ID:         id,
+						FirstName:  data.FirstName,
+						LastName:   data.LastName,
+						Patronymic: data.Patronymic,
+						Age:        data.Age,
+						Sex:        data.Sex,
+						Nation:     data.Nation,
+						Version:    versionArg(p.Args),
+					})
+					if err != nil {
+						return nil, repoError(logger, err)
+					}
+					return people, nil
+				},
+			},
+			"deletePeople": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"version": &graphql.ArgumentConfig{
+						Type:        graphql.Int,
+						Description: "The version the delete is based on; fails on a mismatch.",
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := idArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					if err := usecases.DeletePeopleByID(p.Context, repo, id, versionArg(p.Args)); err != nil {
+						return nil, repoError(logger, err)
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+func idArg(args map[string]any) (uuid.UUID, error) {
+	id, err := uuid.Parse(args["id"].(string))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("id must be a UUID")
+	}
+	return id, nil
+}
+
+func versionArg(args map[string]any) int {
+	version, _ := args["version"].(int)
+	return version
+}
+
+func peopleArg(args map[string]any) dto.CreatePeople {
+	input := args["input"].(map[string]any)
+
+	people := dto.CreatePeople{
+		FirstName: input["firstName"].(string),
+		LastName:  input["lastName"].(string),
+		Sex:       input["sex"].(string),
+	}
+	people.Patronymic, _ = input["patronymic"].(string)
+	people.Age, _ = input["age"].(int)
+	people.Nation, _ = input["nation"].(string)
+	return people
+}
+
+func filterArgs(args map[string]any) (dto.Filter, error) {
+	filter := dto.Filter{Limit: defaultLimit}
+
+	if pagination, ok := args["pagination"].(map[string]any); ok {
+		filter.Limit, _ = pagination["limit"].(int)
+		filter.Offset, _ = pagination["offset"].(int)
+
+		if filter.Limit < 1 || filter.Limit > maxLimit {
+			return dto.Filter{}, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		if filter.Offset < 0 {
+			return dto.Filter{}, fmt.Errorf("offset must not be negative")
+		}
+
+		var err error
+		if sort, ok := pagination["sort"].(string); ok {
+			if filter.Sort, err = dto.ParseSort(sort); err != nil {
+				return dto.Filter{}, err
+			}
+		}
+		if after, ok := pagination["after"].(string); ok && after != "" {
+			if filter.Offset != 0 {
+				return dto.Filter{}, fmt.Errorf("after and offset are mutually exclusive")
+			}
+			if filter.After, err = dto.DecodeCursor(after); err != nil {
+				return dto.Filter{}, fmt.Errorf("after: %w", err)
+			}
+			if !filter.After.Matches(filter.Sort) {
+				return dto.Filter{}, fmt.Errorf("after: cursor was issued for a different sort")
+			}
+			if !filter.After.MatchesAsOf(nil) {
+				return dto.Filter{}, fmt.Errorf("after: cursor was issued for a point in time")
+			}
+		}
+	}
+
+	f, ok := args["filter"].(map[string]any)
+	if !ok {
+		return filter, nil
+	}
+
+	filter.FirstName, _ = f["firstName"].(string)
+	filter.FirstNamePrefix, _ = f["firstNamePrefix"].(string)
+	filter.LastName, _ = f["lastName"].(string)
+	filter.LastNamePrefix, _ = f["lastNamePrefix"].(string)
+	filter.Patronymic, _ = f["patronymic"].(string)
+	filter.PatronymicPrefix, _ = f["patronymicPrefix"].(string)
+	filter.Sex, _ = f["sex"].(string)
+	filter.Deleted, _ = f["deleted"].(bool)
+	filter.IncludeDeleted, _ = f["includeDeleted"].(bool)
+
+	if v, ok := f["ageMin"].(int); ok {
+		filter.AgeMin = &v
+	}
+	if v, ok := f["ageMax"].(int); ok {
+		filter.AgeMax = &v
+	}
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return dto.Filter{}, fmt.Errorf("ageMin must not be greater than ageMax")
+	}
+	if filter.Sex != "" && filter.Sex != "male" && filter.Sex != "female" {
+		return dto.Filter{}, fmt.Errorf("sex must be male | female")
+	}
+
+	if nations, ok := f["nations"].([]any); ok {
+		for _, nation := range nations {
+			if !dto.IsNation(nation.(string)) {
+				return dto.Filter{}, fmt.Errorf("nations must be ISO 3166-1 alpha-2 country codes")
+			}
+			filter.Nations = append(filter.Nations, nation.(string))
+		}
+	}
+
+	if v, ok := f["updatedSince"].(time.Time); ok {
+		filter.UpdatedSince = &v
+	}
+	if v, ok := f["createdBefore"].(time.Time); ok {
+		filter.CreatedBefore = &v
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,272 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeRepo serves a single person. err, if set, fails every call.
+type fakeRepo struct {
+	usecases.IPeopleRepo
+
+	people  dto.People
+	err     error
+	filter  dto.Filter
+	created dto.CreatePeople
+	updated dto.People
+	deleted int
+}
+
+func (f *fakeRepo) GetByID(_ context.Context, id uuid.UUID) (*dto.People, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if id != f.people.ID {
+		return nil, sql.ErrNoRows
+	}
+	people := f.people
+	return &people, nil
+}
+
+func (f *fakeRepo) GetAllByFilter(_ context.Context, filter dto.Filter) (*dto.PeoplesPage, error) {
+	f.filter = filter
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dto.PeoplesPage{Peoples: []dto.People{f.people}, Total: 1}, nil
+}
+
+func (f *fakeRepo) Create(_ context.Context, people dto.CreatePeople) (*dto.People, error) {
+	f.created = people
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dto.People{ID: f.people.ID, FirstName: people.FirstName, LastName: people.LastName, Sex: people.Sex, Version: 1}, nil
+}
+
+func (f *fakeRepo) Update(_ context.Context, people dto.People) (*dto.People, error) {
+	f.updated = people
+	if f.err != nil {
+		return nil, f.err
+	}
+	people.Version++
+	return &people, nil
+}
+
+func (f *fakeRepo) DeleteByID(_ context.Context, _ uuid.UUID, version int) error {
+	f.deleted = version
+	return f.err
+}
+
+func do(t *testing.T, repo usecases.IPeopleRepo, query string) *graphql.Result {
+	t.Helper()
+	schema, err := newSchema(testLogger, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graphql.Do(graphql.Params{Schema: schema, RequestString: query, Context: context.Background()})
+}
+
+func errorMessage(res *graphql.Result) string {
+	if len(res.Errors) == 0 {
+		return ""
+	}
+	return res.Errors[0].Message
+}
+
+const ivanID = "6f1c3b2a-0000-4000-8000-000000000001"
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{people: dto.People{
+		ID:        uuid.MustParse(ivanID),
+		FirstName: "Ivan",
+		LastName:  "Petrov",
+		Sex:       dto.SexMale,
+		Version:   3,
+	}}
+}
+
+func TestPersonQuery(t *testing.T) {
+	repo := newFakeRepo()
+
+	res := do(t, repo, `{ person(id: "`+ivanID+`") { firstName version } }`)
+	if len(res.Errors) > 0 {
+		t.Fatal(res.Errors)
+	}
+	person := res.Data.(map[string]any)["person"].(map[string]any)
+	if person["firstName"] != "Ivan" || person["version"] != 3 {
+		t.Errorf("person = %v", person)
+	}
+
+	// An unknown person is null rather than an error.
+	res = do(t, repo, `{ person(id: "6f1c3b2a-0000-4000-8000-000000000002") { firstName } }`)
+	if len(res.Errors) > 0 || res.Data.(map[string]any)["person"] != nil {
+		t.Errorf("unknown person = %v, %v", res.Data, res.Errors)
+	}
+
+	res = do(t, repo, `{ person(id: "ivan") { firstName } }`)
+	if got := errorMessage(res); got != "id must be a UUID" {
+		t.Errorf("error = %q", got)
+	}
+}
+
+func TestPeoplesQuery(t *testing.T) {
+	repo := newFakeRepo()
+
+	res := do(t, repo, `{ peoples(
+		filter: {firstName: "ivan", ageMin: 18, nations: ["RU", "BY"]}
+		pagination: {limit: 10, sort: "-age"}
+	) { total items { id } } }`)
+	if len(res.Errors) > 0 {
+		t.Fatal(res.Errors)
+	}
+	page := res.Data.(map[string]any)["peoples"].(map[string]any)
+	if page["total"] != 1 || len(page["items"].([]any)) != 1 {
+		t.Errorf("peoples = %v", page)
+	}
+
+	f := repo.filter
+	if f.FirstName != "ivan" || f.Limit != 10 || f.AgeMin == nil || *f.AgeMin != 18 ||
+		len(f.Nations) != 2 || dto.FormatSort(f.Sort) != "-age" {
+		t.Errorf("filter = %+v", f)
+	}
+}
+
+func TestFilterArgsErrors(t *testing.T) {
+	cursor := dto.NewCursor(dto.People{}, nil, nil).Encode()
+	asOf := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pointInTime := dto.NewCursor(dto.People{}, nil, &asOf).Encode()
+	sorted := dto.NewCursor(dto.People{}, []dto.SortField{{Field: "age"}}, nil).Encode()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"zero limit", pagination("limit", 0), "limit must be between 1 and 1000"},
+		{"limit too large", pagination("limit", maxLimit+1), "limit must be between 1 and 1000"},
+		{"negative offset", pagination("offset", -1), "offset must not be negative"},
+		{"unknown sort", pagination("sort", "height"), "sort"},
+		{"after with offset", pagination("after", cursor, "offset", 5), "after and offset are mutually exclusive"},
+		{"bad cursor", pagination("after", "???"), "after:"},
+		{"cursor of another sort", pagination("after", sorted), "after: cursor was issued for a different sort"},
+		{"cursor of a point in time", pagination("after", pointInTime), "after: cursor was issued for a point in time"},
+		{"age range", map[string]any{"filter": map[string]any{"ageMin": 30, "ageMax": 20}}, "ageMin must not be greater than ageMax"},
+		{"sex", map[string]any{"filter": map[string]any{"sex": "other"}}, "sex must be male | female"},
+		{"nation", map[string]any{"filter": map[string]any{"nations": []any{"RU", "russia"}}}, "nations must be ISO 3166-1 alpha-2 country codes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filterArgs(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("filterArgs() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	filter, err := filterArgs(pagination("after", cursor))
+	if err != nil || filter.After == nil {
+		t.Errorf("filterArgs() = %+v, %v, want the cursor", filter, err)
+	}
+}
+
+// pagination returns the arguments of a pagination input with the default
+// limit and offset, overridden by the given key-value pairs.
+func pagination(kv ...any) map[string]any {
+	p := map[string]any{"limit": defaultLimit, "offset": 0}
+	for i := 0; i < len(kv); i += 2 {
+		p[kv[i].(string)] = kv[i+1]
+	}
+	return map[string]any{"pagination": p}
+}
+
+func TestRepoError(t *testing.T) {
+	invalid := &dto.ValidationError{}
+	invalid.Add("age", "must be at most 150")
+
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{invalid, invalid},
+		{sql.ErrNoRows, errNotFound},
+		{dto.ErrVersionConflict, errVersionConflict},
+		{errors.New("connection refused"), errInternal},
+	}
+	for _, tt := range tests {
+		if got := repoError(testLogger, tt.err); got != tt.want {
+			t.Errorf("repoError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCreatePeopleMutation(t *testing.T) {
+	repo := newFakeRepo()
+
+	res := do(t, repo, `mutation { createPeople(input: {firstName: "Anna", lastName: "Ivanova", sex: "female", age: 30}) { id firstName } }`)
+	if len(res.Errors) > 0 {
+		t.Fatal(res.Errors)
+	}
+	if repo.created.FirstName != "Anna" || repo.created.Age != 30 {
+		t.Errorf("created %+v", repo.created)
+	}
+
+	res = do(t, repo, `mutation { createPeople(input: {firstName: "Anna1", lastName: "Ivanova", sex: "female"}) { id } }`)
+	if got := errorMessage(res); !strings.Contains(got, "validation failed: first_name") {
+		t.Errorf("error = %q, want a validation error", got)
+	}
+}
+
+func TestUpdatePeopleMutation(t *testing.T) {
+	repo := newFakeRepo()
+
+	res := do(t, repo, `mutation { updatePeople(id: "`+ivanID+`", version: 3, input: {firstName: "Ivan", lastName: "Sidorov", sex: "male"}) { lastName version } }`)
+	if len(res.Errors) > 0 {
+		t.Fatal(res.Errors)
+	}
+	if repo.updated.ID.String() != ivanID || repo.updated.LastName != "Sidorov" || repo.updated.Version != 3 {
+		t.Errorf("updated %+v", repo.updated)
+	}
+
+	repo.err = dto.ErrVersionConflict
+	res = do(t, repo, `mutation { updatePeople(id: "`+ivanID+`", version: 2, input: {firstName: "Ivan", lastName: "Sidorov", sex: "male"}) { version } }`)
+	if got := errorMessage(res); got != errVersionConflict.Error() {
+		t.Errorf("error = %q, want %q", got, errVersionConflict)
+	}
+}
+
+func TestDeletePeopleMutation(t *testing.T) {
+	repo := newFakeRepo()
+
+	res := do(t, repo, `mutation { deletePeople(id: "`+ivanID+`", version: 3) }`)
+	if len(res.Errors) > 0 {
+		t.Fatal(res.Errors)
+	}
+	if res.Data.(map[string]any)["deletePeople"] != true || repo.deleted != 3 {
+		t.Errorf("deletePeople = %v, version %d", res.Data, repo.deleted)
+	}
+
+	for err, want := range map[error]error{
+		sql.ErrNoRows:                 errNotFound,
+		errors.New("disk is on fire"): errInternal,
+	} {
+		repo.err = err
+		res = do(t, repo, `mutation { deletePeople(id: "`+ivanID+`") }`)
+		if got := errorMessage(res); got != want.Error() {
+			t.Errorf("error = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/graphql-go/graphql"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/graph_config"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/actor"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/logger"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/repo"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+// envProd disables GraphiQL.
+const envProd = "PROD"
+
+type serverCfg struct {
+	addr        string
+	timeout     time.Duration
+	idleTimeout time.Duration
+	graphiQL    bool
+}
+
+type Server struct {
+	logger *slog.Logger
+	repo   *repo.PeopleRepo
+	schema graphql.Schema
+	router *chi.Mux
+	cfg    serverCfg
+}
+
+func NewServerFromConfig(cfg graph_config.Config) (*Server, error) {
+	logger := logging.SetUpLogger(cfg.Env)
+
+	dbConn, err := sqlx.Connect(cfg.Db.Driver, cfg.Db.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect %w", err)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to ping redis %w", err)
+	}
+
+	repos := repo.NewPeopleRepo(logger, dbConn, client, cfg.Redis.Timeout)
+
+	schema, err := newSchema(logger, repos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema %w", err)
+	}
+
+	return &Server{
+		logger: logger,
+		repo:   repos,
+		schema: schema,
+		router: chi.NewRouter(),
+		cfg: serverCfg{
+			addr:        cfg.Server.Address,
+			timeout:     cfg.Server.Timeout,
+			idleTimeout: cfg.Server.IdleTimeout,
+			graphiQL:    cfg.Env != envProd,
+		},
+	}, nil
+}
+
+func (s *Server) setupRoutes() {
+	s.router.Use(middleware.Recoverer)
+	s.router.Use(middleware.RequestID)
+	s.router.Use(logger.New(s.logger))
+	s.router.Use(actor.New())
+
+	s.router.Post("/graphql", s.serveGraphQL)
+	if s.cfg.graphiQL {
+		s.router.Get("/graphql", s.serveGraphiQL)
+	}
+}
+
+func (s *Server) GetHttp() *http.Server {
+	s.setupRoutes()
+	srv := http.Server{
+		Addr:         s.cfg.addr,
+		Handler:      s.router,
+		IdleTimeout:  s.cfg.idleTimeout,
+		ReadTimeout:  s.cfg.timeout,
+		WriteTimeout: s.cfg.timeout,
+	}
+
+	return &srv
+}
+
+func (s *Server) Close(ctx context.Context) error {
+	if err := s.repo.Close(ctx); err != nil {
+		return err
+	}
+	return nil
+}
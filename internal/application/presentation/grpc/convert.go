@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	peoplesv1 "github.com/Dmitrij-Kochetov/peoples/api/peoples/v1"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+var sexes = map[string]peoplesv1.Sex{
+	"male":   peoplesv1.Sex_SEX_MALE,
+	"female": peoplesv1.Sex_SEX_FEMALE,
+}
+
+var eventTypes = map[string]peoplesv1.EventType{
+	dto.EventCreated:  peoplesv1.EventType_EVENT_TYPE_CREATED,
+	dto.EventUpdated:  peoplesv1.EventType_EVENT_TYPE_UPDATED,
+	dto.EventDeleted:  peoplesv1.EventType_EVENT_TYPE_DELETED,
+	dto.EventRestored: peoplesv1.EventType_EVENT_TYPE_RESTORED,
+	dto.EventPurged:   peoplesv1.EventType_EVENT_TYPE_PURGED,
+	dto.EventEnriched: peoplesv1.EventType_EVENT_TYPE_ENRICHED,
+}
+
+func sexFromProto(sex peoplesv1.Sex) string {
+	switch sex {
+	case peoplesv1.Sex_SEX_MALE:
+		return "male"
+	case peoplesv1.Sex_SEX_FEMALE:
+		return "female"
+	}
+	return ""
+}
+
+func newPeople(people *dto.People) *peoplesv1.People {
+	res := &peoplesv1.People{
+		Id:         people.ID.String(),
+		FirstName:  people.FirstName,
+		LastName:   people.LastName,
+		Patronymic: people.Patronymic,
+		Age:        int32(people.Age),
+		Sex:        sexes[people.Sex],
+		Nation:     people.Nation,
+		Deleted:    people.Deleted,
+		Version:    int32(people.Version),
+		CreatedAt:  timestamppb.New(people.CreatedAt),
+		UpdatedAt:  timestamppb.New(people.UpdatedAt),
+	}
+	if people.DeletedAt != nil {
+		res.DeletedAt = timestamppb.New(*people.DeletedAt)
+	}
+	return res
+}
+
+func newEvent(event dto.Event) *peoplesv1.WatchResponse {
+	res := &peoplesv1.WatchResponse{
+		EventId:    event.ID,
+		Type:       eventTypes[event.Type],
+		Actor:      event.Actor,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+	if event.People != nil {
+		res.People = newPeople(event.People)
+	}
+	return res
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	res, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("id must be a UUID")
+	}
+	return res, nil
+}
+
+func parsePeople(input *peoplesv1.PeopleInput) (dto.CreatePeople, error) {
+	if input == nil {
+		return dto.CreatePeople{}, fmt.Errorf("people is required")
+	}
+
+	people := dto.CreatePeople{
+		FirstName:  input.GetFirstName(),
+		LastName:   input.GetLastName(),
+		Patronymic: input.GetPatronymic(),
+		Age:        int(input.GetAge()),
+		Sex:        sexFromProto(input.GetSex()),
+		Nation:     input.GetNation(),
+	}
+	return people, nil
+}
+
+// parseFilter converts the conditions of a request filter. Pagination is
+// left to the caller.
+func parseFilter(f *peoplesv1.Filter) (dto.Filter, error) {
+	var filter dto.Filter
+	if f == nil {
+		return filter, nil
+	}
+
+	filter.FirstName = f.GetFirstName()
+	filter.FirstNamePrefix = f.GetFirstNamePrefix()
+	filter.LastName = f.GetLastName()
+	filter.LastNamePrefix = f.GetLastNamePrefix()
+	filter.Patronymic = f.GetPatronymic()
+	filter.PatronymicPrefix = f.GetPatronymicPrefix()
+	filter.Sex = sexFromProto(f.GetSex())
+	filter.Nations = f.GetNations()
+	filter.Deleted = f.GetDeleted()
+	filter.IncludeDeleted = f.GetIncludeDeleted()
+
+	if f.AgeMin != nil {
+		v := int(f.GetAgeMin())
+		filter.AgeMin = &v
+	}
+	if f.AgeMax != nil {
+		v := int(f.GetAgeMax())
+		filter.AgeMax = &v
+	}
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return dto.Filter{}, fmt.Errorf("age_min must not be greater than age_max")
+	}
+	for _, nation := range filter.Nations {
+		if !dto.IsNation(nation) {
+			return dto.Filter{}, fmt.Errorf("nations must be ISO 3166-1 alpha-2 country codes")
+		}
+	}
+
+	if f.UpdatedSince != nil {
+		t := f.GetUpdatedSince().AsTime()
+		filter.UpdatedSince = &t
+	}
+	if f.CreatedBefore != nil {
+		t := f.GetCreatedBefore().AsTime()
+		filter.CreatedBefore = &t
+	}
+
+	return filter, nil
+}
+
+// parseList converts a list request into a filter with its page.
+func parseList(req *peoplesv1.ListRequest) (dto.Filter, error) {
+	filter, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return dto.Filter{}, err
+	}
+
+	filter.Limit = defaultPageSize
+	if size := req.GetPageSize(); size != 0 {
+		if size < 1 || size > maxPageSize {
+			return dto.Filter{}, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+		filter.Limit = int(size)
+	}
+
+	if filter.Sort, err = dto.ParseSort(req.GetSort()); err != nil {
+		return dto.Filter{}, err
+	}
+	if token := req.GetPageToken(); token != "" {
+		if filter.After, err = dto.DecodeCursor(token); err != nil {
+			return dto.Filter{}, fmt.Errorf("page_token: %w", err)
+		}
+		if !filter.After.Matches(filter.Sort) {
+			return dto.Filter{}, fmt.Errorf("page_token: cursor was issued for a different sort")
+		}
+		if !filter.After.MatchesAsOf(nil) {
+			return dto.Filter{}, fmt.Errorf("page_token: cursor was issued for a point in time")
+		}
+	}
+
+	return filter, nil
+}
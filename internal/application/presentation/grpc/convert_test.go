@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	peoplesv1 "github.com/Dmitrij-Kochetov/peoples/api/peoples/v1"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+func TestParseFilter(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	filter, err := parseFilter(&peoplesv1.Filter{
+		FirstName:      "Ivan",
+		LastNamePrefix: "Pet",
+		AgeMin:         proto.Int32(18),
+		AgeMax:         proto.Int32(30),
+		Sex:            peoplesv1.Sex_SEX_MALE,
+		Nations:        []string{"RU"},
+		UpdatedSince:   timestamppb.New(since),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.FirstName != "Ivan" || filter.LastNamePrefix != "Pet" || filter.Sex != dto.SexMale ||
+		len(filter.Nations) != 1 || *filter.AgeMin != 18 || *filter.AgeMax != 30 ||
+		!filter.UpdatedSince.Equal(since) || filter.CreatedBefore != nil {
+		t.Errorf("parseFilter() = %+v", filter)
+	}
+
+	// An unset age bound stays nil rather than becoming 0.
+	if filter, err := parseFilter(&peoplesv1.Filter{AgeMax: proto.Int32(0)}); err != nil || filter.AgeMin != nil || *filter.AgeMax != 0 {
+		t.Errorf("parseFilter(age_max: 0) = %+v, %v", filter, err)
+	}
+	if filter, err := parseFilter(nil); err != nil || filter.Limit != 0 {
+		t.Errorf("parseFilter(nil) = %+v, %v", filter, err)
+	}
+
+	if _, err := parseFilter(&peoplesv1.Filter{AgeMin: proto.Int32(30), AgeMax: proto.Int32(18)}); err == nil {
+		t.Error("parseFilter() accepted age_min > age_max")
+	}
+	if _, err := parseFilter(&peoplesv1.Filter{Nations: []string{"russia"}}); err == nil {
+		t.Error("parseFilter() accepted a nation that is not a country code")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	asOf := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ageSort := []dto.SortField{{Field: "age"}}
+
+	tests := []struct {
+		name    string
+		req     *peoplesv1.ListRequest
+		limit   int
+		wantErr string
+	}{
+		{"default page size", &peoplesv1.ListRequest{}, defaultPageSize, ""},
+		{"page size", &peoplesv1.ListRequest{PageSize: 10}, 10, ""},
+		{"max page size", &peoplesv1.ListRequest{PageSize: maxPageSize}, maxPageSize, ""},
+		{"cursor", &peoplesv1.ListRequest{Sort: "age", PageToken: dto.NewCursor(dto.People{}, ageSort, nil).Encode()}, defaultPageSize, ""},
+		{"negative page size", &peoplesv1.ListRequest{PageSize: -1}, 0, "page_size must be between 1 and 1000"},
+		{"page size too large", &peoplesv1.ListRequest{PageSize: maxPageSize + 1}, 0, "page_size must be between 1 and 1000"},
+		{"unknown sort", &peoplesv1.ListRequest{Sort: "height"}, 0, "height"},
+		{"bad filter", &peoplesv1.ListRequest{Filter: &peoplesv1.Filter{AgeMin: proto.Int32(2), AgeMax: proto.Int32(1)}}, 0, "age_min"},
+		{"bad token", &peoplesv1.ListRequest{PageToken: "???"}, 0, "page_token:"},
+		{"token of another sort", &peoplesv1.ListRequest{PageToken: dto.NewCursor(dto.People{}, ageSort, nil).Encode()}, 0, "page_token: cursor was issued for a different sort"},
+		{"token of a point in time", &peoplesv1.ListRequest{PageToken: dto.NewCursor(dto.People{}, nil, &asOf).Encode()}, 0, "page_token: cursor was issued for a point in time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseList(tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseList() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filter.Limit != tt.limit {
+				t.Errorf("Limit = %d, want %d", filter.Limit, tt.limit)
+			}
+			if (tt.req.PageToken != "") != (filter.After != nil) {
+				t.Errorf("After = %+v", filter.After)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// actorKey is the metadata key naming the actor of a call, the gRPC
+// counterpart of the X-Actor header.
+const actorKey = "x-actor"
+
+// withActor attributes the changes made by a call to the actor named in
+// its metadata, recorded as claimed by the client.
+func withActor(ctx context.Context) context.Context {
+	var name string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(actorKey); len(values) > 0 {
+			name = values[0]
+		}
+	}
+	return dto.WithActor(ctx, dto.ClaimedActor(name))
+}
+
+func actorUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withActor(ctx), req)
+}
+
+func actorStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: withActor(ss.Context())})
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// recoverUnary logs every call and turns a panic into an Internal error.
+func recoverUnary(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		startTime := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("panic", slog.String("method", info.FullMethod), slog.String("panic", fmt.Sprint(r)))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+			logCall(log, info.FullMethod, err, startTime)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// recoverStream logs every stream and turns a panic into an Internal error.
+func recoverStream(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		startTime := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("panic", slog.String("method", info.FullMethod), slog.String("panic", fmt.Sprint(r)))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+			logCall(log, info.FullMethod, err, startTime)
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func logCall(log *slog.Logger, method string, err error, startTime time.Time) {
+	log.Info(
+		"call completed",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.String("duration", time.Since(startTime).String()),
+	)
+}
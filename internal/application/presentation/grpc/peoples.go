@@ -0,0 +1,224 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	peoplesv1 "github.com/Dmitrij-Kochetov/peoples/api/peoples/v1"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/events"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// repoError maps repository errors onto status codes, hiding the
+// unexpected ones.
+func (s *Server) repoError(err error) error {
+	var invalid *dto.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError(invalid)
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "person not found")
+	case errors.Is(err, dto.ErrVersionConflict):
+		return status.Error(codes.FailedPrecondition, "version conflict")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		s.logger.Error("internal server error", logging.Err(err))
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// validationError is InvalidArgument with the invalid fields as
+// BadRequest details.
+func validationError(err *dto.ValidationError) error {
+	details := &errdetails.BadRequest{}
+	for _, f := range err.Fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(details)
+	if detailsErr != nil {
+		return invalidArgument(err)
+	}
+	return st.Err()
+}
+
+func (s *Server) Get(ctx context.Context, req *peoplesv1.GetRequest) (*peoplesv1.GetResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	var people *dto.People
+	if req.AsOf != nil {
+		people, err = usecases.GetPeopleByIDAsOf(ctx, s.repo, id, req.GetAsOf().AsTime(), req.GetIncludeDeleted())
+	} else {
+		people, err = usecases.GetPeopleByID(ctx, s.repo, id, req.GetIncludeDeleted())
+	}
+	if err != nil {
+		return nil, s.repoError(err)
+	}
+
+	return &peoplesv1.GetResponse{People: newPeople(people)}, nil
+}
+
+func (s *Server) List(ctx context.Context, req *peoplesv1.ListRequest) (*peoplesv1.ListResponse, error) {
+	filter, err := parseList(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	page, err := usecases.GetAllPeopleByFilter(ctx, s.repo, filter)
+	if err != nil {
+		return nil, s.repoError(err)
+	}
+
+	res := &peoplesv1.ListResponse{
+		Peoples:   make([]*peoplesv1.People, len(page.Peoples)),
+		TotalSize: int32(page.Total),
+	}
+	for i := range page.Peoples {
+		res.Peoples[i] = newPeople(&page.Peoples[i])
+	}
+	if page.NextCursor != nil {
+		res.NextPageToken = page.NextCursor.Encode()
+	}
+	return res, nil
+}
+
+func (s *Server) Create(ctx context.Context, req *peoplesv1.CreateRequest) (*peoplesv1.CreateResponse, error) {
+	people, err := parsePeople(req.GetPeople())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	created, err := usecases.CreatePeople(ctx, s.repo, people)
+	if err != nil {
+		return nil, s.repoError(err)
+	}
+
+	return &peoplesv1.CreateResponse{People: newPeople(created)}, nil
+}
+
+func (s *Server) Update(ctx context.Context, req *peoplesv1.UpdateRequest) (*peoplesv1.UpdateResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	data, err := parsePeople(req.GetPeople())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	people, err := usecases.UpdatePeopleByID(ctx, s.repo, dto.People{
+		ID:         id,
+		FirstName:  data.FirstName,
+		LastName:   data.LastName,
+		Patronymic: data.Patronymic,
+		Age:        data.Age,
+		Sex:        data.Sex,
+		Nation:     data.Nation,
+		Version:    int(req.GetVersion()),
+	})
+	if err != nil {
+		return nil, s.repoError(err)
+	}
+
+	return &peoplesv1.UpdateResponse{People: newPeople(people)}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *peoplesv1.DeleteRequest) (*peoplesv1.DeleteResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if err := usecases.DeletePeopleByID(ctx, s.repo, id, int(req.GetVersion())); err != nil {
+		return nil, s.repoError(err)
+	}
+
+	return &peoplesv1.DeleteResponse{}, nil
+}
+
+// Watch streams the changes of people matching the filter, like the SSE
+// stream of the REST server. A client resuming with after_event_id first
+// gets the events it missed from the outbox.
+func (s *Server) Watch(req *peoplesv1.WatchRequest, stream peoplesv1.PeopleService_WatchServer) error {
+	ctx := stream.Context()
+
+	filter, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return invalidArgument(err)
+	}
+	filter.IncludeDeleted = true
+
+	var sendErr error
+	sendPage := func(page []dto.Event) error {
+		for _, event := range page {
+			if sendErr = stream.Send(newEvent(event)); sendErr != nil {
+				return sendErr
+			}
+		}
+		return nil
+	}
+	replay := func(afterID int64) (int64, error) {
+		last, err := events.Replay(ctx, s.repo, afterID, filter, events.ReplayBatch, sendPage)
+		if err != nil && sendErr == nil {
+			err = s.repoError(err)
+		}
+		return last, err
+	}
+
+	// The outbox is replayed before subscribing, so a long replay cannot
+	// overflow the subscription, and once more after it, so nothing
+	// published in between is lost. Live events up to the last replayed
+	// one are skipped.
+	var last int64
+	if req.AfterEventId != nil {
+		if last, err = replay(req.GetAfterEventId()); err != nil {
+			return err
+		}
+	}
+
+	live, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	if req.AfterEventId != nil {
+		if last, err = replay(last); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-live:
+			if !ok {
+				return status.Error(codes.Unavailable, "stream closed, resume with after_event_id")
+			}
+			if event.ID <= last || !filter.Matches(event.People) {
+				continue
+			}
+			if err := stream.Send(newEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
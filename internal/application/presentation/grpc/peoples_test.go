@@ -0,0 +1,249 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	peoplesv1 "github.com/Dmitrij-Kochetov/peoples/api/peoples/v1"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// fakeRepo serves a single person. err, if set, fails every call.
+type fakeRepo struct {
+	usecases.IPeopleRepo
+
+	people dto.People
+	err    error
+}
+
+func (f *fakeRepo) GetByID(_ context.Context, id uuid.UUID) (*dto.People, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if id != f.people.ID {
+		return nil, sql.ErrNoRows
+	}
+	people := f.people
+	return &people, nil
+}
+
+func (f *fakeRepo) Create(_ context.Context, people dto.CreatePeople) (*dto.People, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dto.People{ID: f.people.ID, FirstName: people.FirstName, LastName: people.LastName, Sex: people.Sex, Version: 1}, nil
+}
+
+func (f *fakeRepo) Update(_ context.Context, people dto.People) (*dto.People, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if people.Version != 0 && people.Version != f.people.Version {
+		return nil, dto.ErrVersionConflict
+	}
+	people.Version = f.people.Version + 1
+	return &people, nil
+}
+
+func (f *fakeRepo) DeleteByID(_ context.Context, id uuid.UUID, _ int) error {
+	if f.err != nil {
+		return f.err
+	}
+	if id != f.people.ID {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (f *fakeRepo) Close(context.Context) error {
+	return nil
+}
+
+func TestRepoError(t *testing.T) {
+	s := &Server{logger: testLogger}
+	invalid := &dto.ValidationError{}
+	invalid.Add("age", "must be at most 150")
+
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{invalid, codes.InvalidArgument},
+		{sql.ErrNoRows, codes.NotFound},
+		{dto.ErrVersionConflict, codes.FailedPrecondition},
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		err := s.repoError(tt.err)
+		if got := status.Code(err); got != tt.code {
+			t.Errorf("repoError(%v) code = %v, want %v", tt.err, got, tt.code)
+		}
+	}
+
+	// Unexpected errors are hidden from the client.
+	if msg := status.Convert(s.repoError(errors.New("connection refused"))).Message(); msg != "internal server error" {
+		t.Errorf("internal message = %q", msg)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	invalid := &dto.ValidationError{}
+	invalid.Add("first_name", "is required")
+	invalid.Add("age", "must be at most 150")
+
+	st := status.Convert(validationError(invalid))
+	if st.Code() != codes.InvalidArgument {
+		t.Errorf("code = %v, want InvalidArgument", st.Code())
+	}
+	violations := badRequest(t, st)
+	if len(violations) != 2 || violations[0].GetField() != "first_name" || violations[1].GetDescription() != "must be at most 150" {
+		t.Errorf("field violations = %v", violations)
+	}
+}
+
+func badRequest(t *testing.T, st *status.Status) []*errdetails.BadRequest_FieldViolation {
+	t.Helper()
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			return br.GetFieldViolations()
+		}
+	}
+	t.Fatalf("status %v has no BadRequest details", st)
+	return nil
+}
+
+const ivanID = "6f1c3b2a-0000-4000-8000-000000000001"
+
+// dial serves repo over an in-memory connection and returns its client.
+func dial(t *testing.T, repo *fakeRepo) peoplesv1.PeopleServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := (&Server{logger: testLogger, repo: repo}).newGrpc()
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return peoplesv1.NewPeopleServiceClient(conn)
+}
+
+func TestPeopleServiceCodes(t *testing.T) {
+	repo := &fakeRepo{people: dto.People{
+		ID:        uuid.MustParse(ivanID),
+		FirstName: "Ivan",
+		LastName:  "Petrov",
+		Sex:       dto.SexMale,
+		Version:   3,
+	}}
+	client := dial(t, repo)
+	ctx := context.Background()
+
+	ivan := &peoplesv1.PeopleInput{FirstName: "Ivan", LastName: "Petrov", Sex: peoplesv1.Sex_SEX_MALE}
+	unknownID := "6f1c3b2a-0000-4000-8000-000000000002"
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"get", func() error {
+			_, err := client.Get(ctx, &peoplesv1.GetRequest{Id: ivanID})
+			return err
+		}, codes.OK},
+		{"get bad id", func() error {
+			_, err := client.Get(ctx, &peoplesv1.GetRequest{Id: "ivan"})
+			return err
+		}, codes.InvalidArgument},
+		{"get unknown", func() error {
+			_, err := client.Get(ctx, &peoplesv1.GetRequest{Id: unknownID})
+			return err
+		}, codes.NotFound},
+		{"create", func() error {
+			_, err := client.Create(ctx, &peoplesv1.CreateRequest{People: ivan})
+			return err
+		}, codes.OK},
+		{"create without people", func() error {
+			_, err := client.Create(ctx, &peoplesv1.CreateRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"create invalid", func() error {
+			_, err := client.Create(ctx, &peoplesv1.CreateRequest{People: &peoplesv1.PeopleInput{LastName: "Petrov", Sex: peoplesv1.Sex_SEX_MALE}})
+			return err
+		}, codes.InvalidArgument},
+		{"update", func() error {
+			_, err := client.Update(ctx, &peoplesv1.UpdateRequest{Id: ivanID, People: ivan, Version: 3})
+			return err
+		}, codes.OK},
+		{"update stale version", func() error {
+			_, err := client.Update(ctx, &peoplesv1.UpdateRequest{Id: ivanID, People: ivan, Version: 2})
+			return err
+		}, codes.FailedPrecondition},
+		{"update bad id", func() error {
+			_, err := client.Update(ctx, &peoplesv1.UpdateRequest{Id: "ivan", People: ivan})
+			return err
+		}, codes.InvalidArgument},
+		{"delete", func() error {
+			_, err := client.Delete(ctx, &peoplesv1.DeleteRequest{Id: ivanID})
+			return err
+		}, codes.OK},
+		{"delete unknown", func() error {
+			_, err := client.Delete(ctx, &peoplesv1.DeleteRequest{Id: unknownID})
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.code {
+				t.Errorf("code = %v, want %v", got, tt.code)
+			}
+		})
+	}
+
+	t.Run("create invalid details", func(t *testing.T) {
+		_, err := client.Create(ctx, &peoplesv1.CreateRequest{People: &peoplesv1.PeopleInput{LastName: "Petrov", Sex: peoplesv1.Sex_SEX_MALE}})
+		violations := badRequest(t, status.Convert(err))
+		if len(violations) == 0 || violations[0].GetField() != "first_name" {
+			t.Errorf("field violations = %v", violations)
+		}
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		repo.err = errors.New("connection refused")
+		defer func() { repo.err = nil }()
+
+		_, err := client.Get(ctx, &peoplesv1.GetRequest{Id: ivanID})
+		if st := status.Convert(err); st.Code() != codes.Internal || st.Message() != "internal server error" {
+			t.Errorf("status = %v", st)
+		}
+	})
+}
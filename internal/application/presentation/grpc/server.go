@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	peoplesv1 "github.com/Dmitrij-Kochetov/peoples/api/peoples/v1"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/grpc_config"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/repo"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/events"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+)
+
+// peopleRepo is what the handlers need of repo.PeopleRepo.
+type peopleRepo interface {
+	usecases.IPeopleRepo
+	Close(ctx context.Context) error
+}
+
+type Server struct {
+	peoplesv1.UnimplementedPeopleServiceServer
+
+	logger *slog.Logger
+	repo   peopleRepo
+	events *events.Hub
+	health *health.Server
+	addr   string
+}
+
+func NewServerFromConfig(cfg grpc_config.Config) (*Server, error) {
+	logger := logging.SetUpLogger(cfg.Env)
+
+	dbConn, err := sqlx.Connect(cfg.Db.Driver, cfg.Db.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect %w", err)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to ping redis %w", err)
+	}
+
+	return &Server{
+		logger: logger,
+		repo:   repo.NewPeopleRepo(logger, dbConn, client, cfg.Redis.Timeout),
+		events: events.NewHub(logger, client),
+		health: health.NewServer(),
+		addr:   cfg.Server.Address,
+	}, nil
+}
+
+// GetGrpc registers the people, health and reflection services on a new
+// gRPC server and starts the event hub feeding Watch.
+func (s *Server) GetGrpc() *grpc.Server {
+	srv := s.newGrpc()
+	healthpb.RegisterHealthServer(srv, s.health)
+	reflection.Register(srv)
+
+	s.health.SetServingStatus(peoplesv1.PeopleService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	s.events.Run()
+
+	return srv
+}
+
+// newGrpc returns a gRPC server with the interceptors and the people
+// service.
+func (s *Server) newGrpc() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoverUnary(s.logger), actorUnary),
+		grpc.ChainStreamInterceptor(recoverStream(s.logger), actorStream),
+	)
+	peoplesv1.RegisterPeopleServiceServer(srv, s)
+	return srv
+}
+
+func (s *Server) Listen() (net.Listener, error) {
+	return net.Listen("tcp", s.addr)
+}
+
+// Shutdown reports the server as not serving and ends the open Watch
+// streams, so a graceful stop does not wait for them.
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+	if err := s.events.Close(); err != nil {
+		s.logger.Error("failed to close event hub", logging.Err(err))
+	}
+}
+
+func (s *Server) Close(ctx context.Context) error {
+	if err := s.repo.Close(ctx); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/enrich"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	domain "github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	dto "github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/kafka"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"golang.org/x/sync/errgroup"
+)
+
+// holdRetry is the least a batch waits before its enrichment is tried
+// again after the providers failed.
+const holdRetry = 5 * time.Second
+
+// errClosed is returned for a batch held until the server shut down.
+var errClosed = errors.New("server closed")
+
+// processBatch enriches and persists the people of msgs, then commits
+// them all. A batch held until the server shuts down, waiting for the
+// providers or the database, is not committed, so it is consumed again.
+func (s *Server) processBatch(msgs []*kafka.Message) {
+	s.logger.Info("batch received", slog.Int("messages", len(msgs)))
+
+	payloads := make([]dto.PeopleName, 0, len(msgs))
+	for _, msg := range msgs {
+		var payload dto.PeopleName
+		if err := json.Unmarshal(msg.Value, &payload); err != nil {
+			s.handleError(dto.Error{
+				Message: "failed to unmarshal payload",
+				Error:   err.Error(),
+			})
+			continue
+		}
+		if err := payload.Validate(); err != nil {
+			s.handleError(dto.NewError("validation failed", err))
+			continue
+		}
+		payloads = append(payloads, payload)
+	}
+
+	if len(payloads) > 0 {
+		ctx := domain.WithActor(context.Background(), actorName)
+		if err := s.persist(ctx, payloads); err != nil {
+			return
+		}
+	}
+
+	s.commit(msgs)
+}
+
+// persist creates the people named in payloads. The people that cannot be
+// created, being invalid or unknown to the providers, are reported to the
+// error topic. Other failures are retried, so it only fails with
+// errClosed.
+func (s *Server) persist(ctx context.Context, payloads []dto.PeopleName) error {
+	names := make([]string, len(payloads))
+	for i, payload := range payloads {
+		names[i] = *payload.FirstName
+	}
+
+	infos, err := s.agify(ctx, names)
+	if errors.Is(err, errClosed) {
+		return err
+	}
+	if err != nil {
+		for range payloads {
+			s.handleError(dto.Error{
+				Message: err.Error(),
+				Error:   "agified failed",
+			})
+		}
+		return nil
+	}
+
+	var g errgroup.Group
+	for i := range payloads {
+		if !infos[i].Valid().Known() {
+			s.handleError(dto.Error{
+				Message: usecases.ErrUnknownName.Error(),
+				Error:   "agified failed",
+			})
+			continue
+		}
+
+		payload, info := payloads[i], infos[i]
+		g.Go(func() error {
+			return s.create(ctx, payload, info)
+		})
+	}
+	return g.Wait()
+}
+
+// create creates the person of payload, holding on while the database
+// fails. A person that cannot be created is reported instead. It returns
+// errClosed if the server is shut down meanwhile.
+func (s *Server) create(ctx context.Context, payload dto.PeopleName, info usecases.AgifyInfo) error {
+	for {
+		err := usecases.CreateAgifiedPeople(ctx, s.peopleRepo, payload, info)
+		var invalid *domain.ValidationError
+		switch {
+		case err == nil:
+			return nil
+		case errors.As(err, &invalid) || errors.Is(err, usecases.ErrUnknownName):
+			s.handleError(dto.NewError("create agified failed", err))
+			return nil
+		}
+
+		s.logger.Warn("failed to create people, holding batch",
+			logging.Err(err),
+			slog.Duration("retry_in", holdRetry),
+		)
+
+		select {
+		case <-s.closeChan:
+			return errClosed
+		case <-time.After(holdRetry):
+		}
+	}
+}
+
+// agify enriches names, holding on while the providers are unavailable.
+// It returns errClosed if the server is shut down meanwhile.
+func (s *Server) agify(ctx context.Context, names []string) ([]usecases.AgifyInfo, error) {
+	for {
+		enrichCtx, cancel := context.WithTimeout(ctx, s.opts.Deadline)
+		infos, err := usecases.AgifyPeoples(enrichCtx, s.cache, s.enricher, names)
+		cancel()
+		if err == nil || !enrich.Retryable(err) {
+			return infos, err
+		}
+
+		wait := time.Until(s.enricher.OpenUntil())
+		if wait < holdRetry {
+			wait = holdRetry
+		}
+		s.logger.Warn("enrichment unavailable, holding batch",
+			logging.Err(err),
+			slog.Duration("retry_in", wait),
+		)
+
+		select {
+		case <-s.closeChan:
+			return nil, errClosed
+		case <-time.After(wait):
+		}
+	}
+}
+
+// commit commits the offsets following msgs on each of their partitions.
+func (s *Server) commit(msgs []*kafka.Message) {
+	if _, err := s.consumer.Consumer.CommitOffsets(nextOffsets(msgs)); err != nil {
+		s.logger.Error("commit failed", logging.Err(err))
+	}
+}
+
+// nextOffsets returns the offset following the last of msgs on each of
+// their partitions, ordered by topic and partition.
+func nextOffsets(msgs []*kafka.Message) []kafka.TopicPartition {
+	next := make(map[string]kafka.TopicPartition)
+	for _, msg := range msgs {
+		tp := msg.TopicPartition
+		key := fmt.Sprintf("%s/%d", *tp.Topic, tp.Partition)
+		if committed, ok := next[key]; !ok || tp.Offset >= committed.Offset {
+			tp.Offset++
+			next[key] = tp
+		}
+	}
+
+	offsets := make([]kafka.TopicPartition, 0, len(next))
+	for _, tp := range next {
+		offsets = append(offsets, tp)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		if *offsets[i].Topic != *offsets[j].Topic {
+			return *offsets[i].Topic < *offsets[j].Topic
+		}
+		return offsets[i].Partition < offsets[j].Partition
+	})
+	return offsets
+}
+
+func (s *Server) writeError(e dto.Error) error {
+	payloadBytes, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	err = s.producer.Producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &s.producer.Topic,
+			Partition: kafka.PartitionAny,
+		},
+		Value: payloadBytes,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleError(e dto.Error) {
+	s.logger.Error(e.Message, slog.Attr{
+		Key:   "error",
+		Value: slog.StringValue(e.Error),
+	})
+
+	err := s.writeError(e)
+	if err != nil {
+		s.logger.Error("failed to write error to kafka", logging.Err(err))
+	}
+}
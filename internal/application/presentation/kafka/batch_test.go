@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func message(topic string, partition int32, offset kafka.Offset) *kafka.Message {
+	return &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}}
+}
+
+func TestNextOffsets(t *testing.T) {
+	got := nextOffsets([]*kafka.Message{
+		message("FIO", 1, 7),
+		message("FIO", 0, 3),
+		message("FIO", 1, 5),
+		message("FIO", 0, 4),
+		message("AUDIT", 0, 10),
+	})
+
+	type offset struct {
+		topic     string
+		partition int32
+		offset    kafka.Offset
+	}
+	var offsets []offset
+	for _, tp := range got {
+		offsets = append(offsets, offset{*tp.Topic, tp.Partition, tp.Offset})
+	}
+
+	// The offset following the last message of each partition, whatever
+	// the order the messages came in.
+	want := []offset{{"AUDIT", 0, 11}, {"FIO", 0, 5}, {"FIO", 1, 8}}
+	if !reflect.DeepEqual(offsets, want) {
+		t.Errorf("nextOffsets() = %v, want %v", offsets, want)
+	}
+
+	if got := nextOffsets(nil); len(got) != 0 {
+		t.Errorf("nextOffsets(nil) = %v, want none", got)
+	}
+}
@@ -2,27 +2,61 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
-	"sync"
+	"net/http"
+	"time"
 
+	cache "github.com/Dmitrij-Kochetov/peoples/internal/adapter/cache/repo"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/kafka_config"
 	db "github.com/Dmitrij-Kochetov/peoples/internal/adapter/database/repo"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/enrich"
 	internal "github.com/Dmitrij-Kochetov/peoples/internal/adapter/kafka"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
 	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
-	dto "github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/kafka"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 )
 
+// actorName is recorded in the people history for changes made by the
+// consumer.
+const actorName = "peoples_kafka"
+
+// Enricher is a usecases.BatchEnricher telling until when its providers
+// should not be called, zero if they can be.
+type Enricher interface {
+	usecases.BatchEnricher
+	OpenUntil() time.Time
+}
+
+// Options tune how a Server consumes.
+type Options struct {
+	// BatchSize and BatchLinger bound how many messages are enriched
+	// together and how long the first of them waits for the others.
+	BatchSize   int
+	BatchLinger time.Duration
+	// Deadline bounds the enrichment of a batch.
+	Deadline time.Duration
+	// StatsEvery is how often the cache hits and misses are logged.
+	StatsEvery time.Duration
+	// MetricsAddr is where the expvar counters are served at
+	// /debug/vars, none if empty.
+	MetricsAddr string
+}
+
 type Server struct {
 	logger     *slog.Logger
 	consumer   *internal.Consumer
 	producer   *internal.Producer
 	peopleRepo *db.DbPeopleRepo
+	enricher   Enricher
+	cache      *cache.CacheAgifyRepo
+	opts       Options
+	metrics    *http.Server
 	doneChan   chan struct{}
 	closeChan  chan struct{}
 }
@@ -52,134 +86,148 @@ func NewServerFromConfig(config kafka_config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to create kafka producer %w", err)
 	}
 
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     config.Redis.Address,
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
+	})
+
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to ping redis %w", err)
+	}
+
+	agifyCache := cache.NewCacheAgifyRepo(redisClient, config.Redis.TTL, config.Redis.UnknownTTL)
+
+	client := enrich.NewClient(config.Enrich.Timeout, config.Enrich.MaxConnsPerHost)
+	enricher := enrich.NewAgify(client, enrich.Config{
+		AgeURL:    config.Enrich.AgeURL,
+		GenderURL: config.Enrich.GenderURL,
+		NationURL: config.Enrich.NationURL,
+		APIKey:    config.Enrich.APIKey,
+
+		Retries:         config.Enrich.Retries,
+		RetryBaseDelay:  config.Enrich.RetryBaseDelay,
+		RetryMaxDelay:   config.Enrich.RetryMaxDelay,
+		BreakerFailures: config.Enrich.BreakerFailures,
+		BreakerCooldown: config.Enrich.BreakerCooldown,
+	})
+
+	expvar.Publish("agify_cache", expvar.Func(func() any {
+		return agifyCache.Stats()
+	}))
+
+	return NewServer(logger, consumer, producer, peopleRepo, enricher, agifyCache, Options{
+		BatchSize:   config.Kafka.BatchSize,
+		BatchLinger: config.Kafka.BatchLinger,
+		Deadline:    config.Enrich.Deadline,
+		StatsEvery:  config.Redis.StatsInterval,
+		MetricsAddr: config.Metrics.Address,
+	})
+}
+
+// NewServer creates a server enriching the consumed names in batches with
+// enricher through agifyCache. While the providers of enricher are
+// unavailable consumption is paused, and the batch being enriched is held
+// rather than given up.
+func NewServer(logger *slog.Logger,
+	consumer *internal.Consumer,
+	producer *internal.Producer,
+	peopleRepo *db.DbPeopleRepo,
+	enricher Enricher,
+	agifyCache *cache.CacheAgifyRepo,
+	opts Options,
+) (*Server, error) {
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 1
+	}
+	if opts.StatsEvery <= 0 {
+		return nil, fmt.Errorf("cache stats interval must be positive")
+	}
+
+	var metrics *http.Server
+	if opts.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+		metrics = &http.Server{Addr: opts.MetricsAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	}
+
 	return &Server{
 		logger:     logger,
 		consumer:   consumer,
 		producer:   producer,
 		peopleRepo: peopleRepo,
+		enricher:   enricher,
+		cache:      agifyCache,
+		opts:       opts,
+		metrics:    metrics,
 		doneChan:   make(chan struct{}),
 		closeChan:  make(chan struct{}),
 	}, nil
 }
 
 func (s *Server) ListenAndServe() error {
-	commit := func(msg *kafka.Message) {
-		if _, err := s.consumer.Consumer.CommitMessage(msg); err != nil {
-			s.logger.Error("commit failed", logging.Err(err))
-		}
-	}
-
-	writeError := func(e dto.Error) error {
-		payloadBytes, err := json.Marshal(e)
-		if err != nil {
-			return err
-		}
-
-		err = s.producer.Producer.Produce(&kafka.Message{
-			TopicPartition: kafka.TopicPartition{
-				Topic:     &s.producer.Topic,
-				Partition: kafka.PartitionAny,
-			},
-			Value: payloadBytes,
-		}, nil)
-		if err != nil {
-			return err
-		}
-		return nil
+	go s.logCacheStats()
+	if s.metrics != nil {
+		go func() {
+			if err := s.metrics.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("metrics server failed", logging.Err(err))
+			}
+		}()
 	}
 
-	handleError := func(e dto.Error) {
-		s.logger.Error(e.Message, slog.Attr{
-			Key:   "error",
-			Value: slog.StringValue(e.Error),
-		})
-
-		err := writeError(e)
-		if err != nil {
-			s.logger.Error("failed to write error to kafka", logging.Err(err))
+	batches := make(chan []*kafka.Message)
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		for batch := range batches {
+			s.processBatch(batch)
 		}
-	}
+	}()
 
 	go func() {
-		run := true
+		var batch []*kafka.Message
+		var lingerUntil time.Time
+		paused := false
 
-		wg := sync.WaitGroup{}
-
-		for run {
+		for run := true; run; {
 			select {
 			case <-s.closeChan:
 				run = false
-				wg.Wait()
-				break
 			default:
-				msg, ok := s.consumer.Consumer.Poll(s.consumer.TimeoutMs).(*kafka.Message)
-				if !ok {
-					continue
-				}
-				s.logger.Info("message received")
-
-				var payload dto.PeopleName
-				if err := json.Unmarshal(msg.Value, &payload); err != nil {
-					go func() {
-						wg.Add(1)
-						defer wg.Done()
-						handleError(dto.Error{
-							Message: "failed to unmarshal payload",
-							Error:   err.Error(),
-						})
-					}()
-					commit(msg)
-					continue
+				timeoutMs := s.consumer.TimeoutMs
+				if len(batch) > 0 {
+					if len(batch) >= s.opts.BatchSize || !time.Now().Before(lingerUntil) {
+						select {
+						case batches <- batch:
+							batch = nil
+						default:
+							// The previous batch is still being processed.
+						}
+					} else if linger := int(time.Until(lingerUntil).Milliseconds()) + 1; linger < timeoutMs {
+						timeoutMs = linger
+					}
 				}
 
-				if payload.FirstName == nil || payload.LastName == nil {
-					go func() {
-						wg.Add(1)
-						defer wg.Done()
-						handleError(dto.ErrRequiredFieldNotExists)
-					}()
+				// Poll keeps being called while paused, so the consumer
+				// stays in its group.
+				open := s.enricher.OpenUntil().After(time.Now())
+				paused = s.setPaused(paused, open || len(batch) >= s.opts.BatchSize)
 
-					commit(msg)
+				msg, ok := s.consumer.Consumer.Poll(timeoutMs).(*kafka.Message)
+				if !ok {
 					continue
 				}
-
-				if *payload.FirstName == "" || *payload.LastName == "" {
-					go func() {
-						wg.Add(1)
-						defer wg.Done()
-						handleError(dto.ErrRequiredFieldIsEmpty)
-					}()
-
-					commit(msg)
-					continue
+				if len(batch) == 0 {
+					lingerUntil = time.Now().Add(s.opts.BatchLinger)
 				}
-
-				go func() {
-					wg.Add(1)
-					defer wg.Done()
-					agifyInfo, err := usecases.AgifyPeople(*payload.FirstName)
-					if err != nil {
-						handleError(dto.Error{
-							Message: err.Error(),
-							Error:   "agified failed",
-						})
-						commit(msg)
-						return
-					}
-					err = usecases.CreateAgifiedPeople(s.peopleRepo, payload, agifyInfo)
-					if err != nil {
-						handleError(dto.Error{
-							Message: err.Error(),
-							Error:   "create agified failed",
-						})
-						commit(msg)
-						return
-					}
-					commit(msg)
-					return
-				}()
+				batch = append(batch, msg)
 			}
 		}
+
+		// The batch still being collected is left uncommitted to be
+		// consumed again.
+		close(batches)
+		<-processed
 		s.logger.Info("consumer stopped")
 		s.doneChan <- struct{}{}
 	}()
@@ -187,10 +235,64 @@ func (s *Server) ListenAndServe() error {
 	return nil
 }
 
+// setPaused pauses or resumes the assigned partitions, and returns
+// whether consumption is paused.
+func (s *Server) setPaused(paused, pause bool) bool {
+	if pause == paused {
+		return paused
+	}
+
+	assignment, err := s.consumer.Consumer.Assignment()
+	if err != nil {
+		s.logger.Error("failed to get assignment", logging.Err(err))
+		return paused
+	}
+
+	if pause {
+		err = s.consumer.Consumer.Pause(assignment)
+	} else {
+		err = s.consumer.Consumer.Resume(assignment)
+	}
+	if err != nil {
+		s.logger.Error("failed to pause consumption", logging.Err(err), slog.Bool("pause", pause))
+		return paused
+	}
+
+	s.logger.Debug("consumption paused", slog.Bool("paused", pause))
+	return pause
+}
+
+// logCacheStats logs the enrichment cache counters until the server is
+// shut down.
+func (s *Server) logCacheStats() {
+	ticker := time.NewTicker(s.opts.StatsEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closeChan:
+			return
+		case <-ticker.C:
+			stats := s.cache.Stats()
+			s.logger.Info("agify cache stats",
+				slog.Int64("hits", stats.Hits),
+				slog.Int64("misses", stats.Misses),
+				slog.Int64("errors", stats.Errors),
+			)
+		}
+	}
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down")
 	close(s.closeChan)
 
+	if s.metrics != nil {
+		if err := s.metrics.Shutdown(ctx); err != nil {
+			s.logger.Error("failed to shut down metrics server", logging.Err(err))
+		}
+	}
+
 	for {
 		select {
 		case <-s.doneChan:
@@ -209,5 +311,8 @@ func (s *Server) Close() error {
 	if err := s.peopleRepo.DB.Close(); err != nil {
 		return err
 	}
+	if err := s.cache.Client.Close(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,19 @@
+package kafka
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNewServerValidatesStatsInterval(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if _, err := NewServer(logger, nil, nil, nil, nil, nil, Options{}); err == nil {
+		t.Error("NewServer() accepted a zero stats interval")
+	}
+	if _, err := NewServer(logger, nil, nil, nil, nil, nil, Options{StatsEvery: time.Minute}); err != nil {
+		t.Errorf("NewServer() error = %v", err)
+	}
+}
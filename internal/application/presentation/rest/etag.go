@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+var errPreconditionFailed = errors.New("malformed If-Match header")
+
+// setETag exposes the version of a person as a strong entity tag.
+func setETag(w http.ResponseWriter, people *dto.People) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(people.Version)+`"`)
+}
+
+// ifMatchVersion returns the version required by the If-Match header. It
+// returns 0, which matches any version, when the header is absent or "*".
+// Weak tags never match, as If-Match uses strong comparison.
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, errPreconditionFailed
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, errPreconditionFailed
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
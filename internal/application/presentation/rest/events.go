@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/events"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/rest"
+)
+
+// keepAlive is how often an idle stream gets a comment, so proxies do not
+// time it out.
+const keepAlive = 15 * time.Second
+
+// streamPeopleEvents streams the changes of people matching the filter
+// conditions of a list as Server-Sent Events; the pagination and as_of
+// parameters are refused. Deleted people are always included, so
+// delete events reach the client. A client resuming with Last-Event-ID
+// first gets the events it missed from the outbox.
+func (s *Server) streamPeopleEvents(w http.ResponseWriter, r *http.Request) {
+	data := &rest.EventFilterRequest{}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+	filter := dto.Filter(*data)
+	filter.IncludeDeleted = true
+
+	lastID, err := rest.LastEventID(r)
+	if err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Error("failed to disable write deadline", logging.Err(err))
+		s.handleError(w, r, rest.ErrInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	writePage := func(page []dto.Event) error {
+		for _, event := range page {
+			if err := writeEvent(w, event); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	}
+
+	// The outbox is replayed before subscribing, so a long replay cannot
+	// overflow the subscription, and once more after it, so nothing
+	// published in between is lost. Live events up to the last replayed
+	// one are skipped.
+	var last int64
+	if lastID != nil {
+		if last, err = events.Replay(r.Context(), s.repo, *lastID, filter, events.ReplayBatch, writePage); err != nil {
+			s.logger.Error("failed to replay events", logging.Err(err))
+			return
+		}
+	}
+
+	live, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	if lastID != nil {
+		if last, err = events.Replay(r.Context(), s.repo, last, filter, events.ReplayBatch, writePage); err != nil {
+			s.logger.Error("failed to replay events", logging.Err(err))
+			return
+		}
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			if event.ID <= last || !filter.Matches(event.People) {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event dto.Event) error {
+	data, err := json.Marshal(rest.NewEventResponse(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
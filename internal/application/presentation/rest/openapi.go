@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Dmitrij-Kochetov/peoples/api/openapi"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+)
+
+// loadSpec parses and validates the OpenAPI document of the API.
+func loadSpec() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec %w", err)
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec %w", err)
+	}
+	return spec, nil
+}
+
+func (s *Server) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(s.spec)
+	if err != nil {
+		s.logger.Error("failed to marshal openapi spec", logging.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		s.logger.Error("failed to write response", logging.Err(err))
+	}
+}
+
+func (s *Server) serveSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(swaggerUI)); err != nil {
+		s.logger.Error("failed to write response", logging.Err(err))
+	}
+}
+
+// swaggerUI is the Swagger UI page, showing the spec served next to it.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Peoples API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script crossorigin src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: new URL('openapi.json', window.location.href).pathname,
+      dom_id: '#swagger-ui',
+    });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/validator"
+)
+
+// undocumented are the routes serving the spec itself.
+var undocumented = map[string]bool{
+	"/api/v1/openapi.json": true,
+	"/api/v1/docs":         true,
+}
+
+func TestSpecCoversRoutes(t *testing.T) {
+	spec, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		router: chi.NewRouter(),
+		spec:   spec,
+	}
+	s.setupRoutes()
+
+	err = chi.Walk(s.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if undocumented[route] {
+			return nil
+		}
+
+		path := spec.Paths.Find(route)
+		if path == nil {
+			t.Errorf("%s %s is not in the spec", method, route)
+			return nil
+		}
+		if path.GetOperation(method) == nil {
+			t.Errorf("%s %s is not in the spec", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	spec, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate, err := validator.New(slog.New(slog.NewTextHandler(io.Discard, nil)), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The body must still be readable behind the validator.
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name, method, target, contentType, body string
+		want                                    int
+	}{
+		{"valid create", http.MethodPost, "/api/v1/peoples", "application/json",
+			`{"first_name":"Ivan","last_name":"Ivanov","sex":"male"}`, http.StatusNoContent},
+		{"create without last name", http.MethodPost, "/api/v1/peoples", "application/json",
+			`{"first_name":"Ivan","sex":"male"}`, http.StatusBadRequest},
+		{"create with unknown sex", http.MethodPost, "/api/v1/peoples", "application/json",
+			`{"first_name":"Ivan","last_name":"Ivanov","sex":"robot"}`, http.StatusBadRequest},
+		{"merge patch", http.MethodPatch, "/api/v1/peoples/0b7cd4c2-6f8e-4b4e-8f3a-2e4f0c7d1a11", "application/merge-patch+json",
+			`{"patronymic":null}`, http.StatusNoContent},
+		{"malformed id", http.MethodGet, "/api/v1/peoples/42", "", "", http.StatusBadRequest},
+		{"limit out of range", http.MethodGet, "/api/v1/peoples?limit=0", "", "", http.StatusBadRequest},
+		{"undocumented path", http.MethodGet, "/api/v2/peoples", "", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/rest"
+)
+
+// setPaginationHeaders writes X-Total-Count and RFC 5988 Link headers for
+// a page of the list endpoint.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, page *dto.PeoplesPage, filter rest.FilterRequest) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+
+	var links []string
+
+	if page.NextCursor != nil {
+		if filter.After != nil {
+			links = append(links, pageLink(r, "next", map[string]string{
+				"after": page.NextCursor.Encode(),
+			}))
+		} else {
+			links = append(links, pageLink(r, "next", map[string]string{
+				"offset": strconv.Itoa(filter.Offset + filter.Limit),
+			}))
+		}
+	}
+
+	if filter.After == nil && filter.Offset > 0 {
+		links = append(links, pageLink(r, "prev", map[string]string{
+			"offset": strconv.Itoa(max(filter.Offset-filter.Limit, 0)),
+		}))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func pageLink(r *http.Request, rel string, params map[string]string) string {
+	query := r.URL.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
@@ -1,12 +1,16 @@
 package rest
 
 import (
-	"context"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/actor"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/logger"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
 	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
@@ -24,14 +28,38 @@ func (s *Server) setupRoutes() {
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.RequestID)
 	s.router.Use(logger.New(s.logger))
+	s.router.Use(actor.New())
 
 	s.router.Route("/api/v1", func(r chi.Router) {
-		r.Route("/peoples", func(r chi.Router) {
-			r.Get("/", s.getPeoples)
-			r.Get("/{id}", s.getPeople)
-			r.Post("/", s.createPeople)
-			r.Put("/{id}", s.updatePeople)
-			r.Delete("/{id}", s.deletePeople)
+		r.Get("/openapi.json", s.serveOpenAPI)
+		r.Get("/docs", s.serveSwaggerUI)
+
+		r.Group(func(r chi.Router) {
+			if s.validator != nil {
+				r.Use(s.validator)
+			}
+
+			r.Post("/peoples:batch", s.batchPeoples)
+			r.Route("/peoples", func(r chi.Router) {
+				r.Get("/", s.getPeoples)
+				r.Get("/search", s.searchPeoples)
+				r.Get("/events", s.streamPeopleEvents)
+				r.Get("/{id}", s.getPeople)
+				r.Post("/", s.createPeople)
+				r.Put("/{id}", s.updatePeople)
+				r.Patch("/{id}", s.patchPeople)
+				r.Delete("/{id}", s.deletePeople)
+				r.Post("/{id}/restore", s.restorePeople)
+				r.Get("/{id}/history", s.getPeopleHistory)
+			})
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", s.getWebhooks)
+				r.Get("/{id}", s.getWebhook)
+				r.Post("/", s.createWebhook)
+				r.Put("/{id}", s.updateWebhook)
+				r.Delete("/{id}", s.deleteWebhook)
+				r.Get("/{id}/deliveries", s.getWebhookDeliveries)
+			})
 		})
 	})
 }
@@ -43,22 +71,88 @@ func (s *Server) handleError(w http.ResponseWriter, r *http.Request, e *rest.Err
 	}
 }
 
+func (s *Server) getPeopleHistory(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	data := &rest.PageRequest{}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	page, err := usecases.GetPeopleHistory(r.Context(), s.repo, id, data.Limit, data.Offset)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if err := render.Render(w, r, rest.NewListHistoryResponse(page, *data)); err != nil {
+		s.logger.Error("failed to render", logging.Err(err))
+	}
+}
+
+// handleRepoError renders an error returned by the people usecases.
+func (s *Server) handleRepoError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *dto.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		s.handleError(w, r, rest.ErrUnprocessableEntity(err))
+	case errors.Is(err, sql.ErrNoRows):
+		s.handleError(w, r, rest.ErrNotFound)
+	case errors.Is(err, dto.ErrVersionConflict):
+		s.handleError(w, r, rest.ErrPreconditionFailed)
+	default:
+		s.logger.Error("internal server error", logging.Err(err))
+		s.handleError(w, r, rest.ErrInternalServerError)
+	}
+}
+
 func (s *Server) getPeoples(w http.ResponseWriter, r *http.Request) {
 	data := &rest.FilterRequest{}
-	if err := render.Bind(r, data); err != nil {
+	if err := data.Bind(r); err != nil {
 		s.logger.Error("bad request", logging.Err(err))
 		s.handleError(w, r, rest.ErrBadRequest)
 		return
 	}
 
-	peoples, err := usecases.GetAllPeopleByFilter(context.Background(), s.repo, dto.Filter(*data))
+	page, err := usecases.GetAllPeopleByFilter(r.Context(), s.repo, dto.Filter(*data))
 	if err != nil {
 		s.logger.Error("failed to get peoples", logging.Err(err))
 		s.handleError(w, r, rest.ErrInternalServerError)
 		return
 	}
 
-	if err := render.RenderList(w, r, rest.NewListPeopleResponse(peoples)); err != nil {
+	setPaginationHeaders(w, r, page, *data)
+
+	if err := render.Render(w, r, rest.NewListPeopleResponse(page, *data)); err != nil {
+		s.logger.Error("failed to render", logging.Err(err))
+	}
+}
+
+func (s *Server) searchPeoples(w http.ResponseWriter, r *http.Request) {
+	data := &rest.SearchRequest{}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	hits, err := usecases.SearchPeople(r.Context(), s.repo, data.Query, data.Limit)
+	if err != nil {
+		s.logger.Error("failed to search peoples", logging.Err(err))
+		s.handleError(w, r, rest.ErrInternalServerError)
+		return
+	}
+
+	if err := render.RenderList(w, r, rest.NewListSearchHitResponse(hits)); err != nil {
 		s.logger.Error("failed to render", logging.Err(err))
 	}
 }
@@ -72,17 +166,35 @@ func (s *Server) getPeople(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	people, err := usecases.GetPeopleByID(context.Background(), s.repo, id)
+	includeDeleted, err := boolParam(r, "include_deleted")
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.handleError(w, r, rest.ErrNotFound)
-		} else {
-			s.logger.Info("error getting people by id", logging.Err(err))
-			s.handleError(w, r, rest.ErrInternalServerError)
-		}
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	asOf, err := rest.AsOf(r)
+	if err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	var people *dto.People
+	if asOf != nil {
+		people, err = usecases.GetPeopleByIDAsOf(r.Context(), s.repo, id, *asOf, includeDeleted)
+	} else {
+		people, err = usecases.GetPeopleByID(r.Context(), s.repo, id, includeDeleted)
+	}
+	if err != nil {
+		s.handleRepoError(w, r, err)
 		return
 	}
 
+	// A past state is not something If-Match could refer to.
+	if asOf == nil {
+		setETag(w, people)
+	}
 	pr := rest.NewPeopleResponse(*people)
 	if err := render.Render(w, r, pr); err != nil {
 		s.logger.Error("error rendering", logging.Err(err))
@@ -97,22 +209,17 @@ func (s *Server) createPeople(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data.Sex != "male" && data.Sex != "female" {
-		s.logger.Error("bad request", logging.Err(fmt.Errorf("sex must be male | female")))
-		s.handleError(w, r, rest.ErrBadRequest)
-		return
-	}
-
-	err := usecases.CreatePeople(context.Background(), s.repo, dto.CreatePeople(*data))
+	people, err := usecases.CreatePeople(r.Context(), s.repo, dto.CreatePeople(*data))
 	if err != nil {
-		s.logger.Error("internal server error", logging.Err(err))
-		s.handleError(w, r, rest.ErrInternalServerError)
+		s.handleRepoError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(200)
-	if _, err = w.Write(nil); err != nil {
-		s.logger.Error("failed to write response", logging.Err(err))
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/peoples/%s", people.ID))
+	setETag(w, people)
+	render.Status(r, http.StatusCreated)
+	if err := render.Render(w, r, rest.NewPeopleResponse(*people)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
 	}
 }
 
@@ -132,13 +239,13 @@ func (s *Server) updatePeople(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data.Sex != "male" && data.Sex != "female" {
-		s.logger.Error("bad request", logging.Err(fmt.Errorf("sex must be male | female")))
-		s.handleError(w, r, rest.ErrBadRequest)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		s.handleError(w, r, rest.ErrPreconditionFailed)
 		return
 	}
 
-	if err := usecases.UpdatePeopleByID(context.Background(), s.repo, dto.People{
+	people, err := usecases.UpdatePeopleByID(r.Context(), s.repo, dto.People{
 		ID:         id,
 		FirstName:  data.FirstName,
 		LastName:   data.LastName,
@@ -146,14 +253,57 @@ func (s *Server) updatePeople(w http.ResponseWriter, r *http.Request) {
 		Age:        data.Age,
 		Sex:        data.Sex,
 		Nation:     data.Nation,
-	}); err != nil {
-		s.logger.Error("internal serever error", logging.Err(err))
-		s.handleError(w, r, rest.ErrInternalServerError)
+		Version:    version,
+	})
+	if err != nil {
+		s.handleRepoError(w, r, err)
 		return
 	}
 
-	if _, err = w.Write(nil); err != nil {
-		s.logger.Error("failed to write response", logging.Err(err))
+	setETag(w, people)
+	if err := render.Render(w, r, rest.NewPeopleResponse(*people)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+func (s *Server) patchPeople(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	// render.Bind does not know application/merge-patch+json, so the
+	// patch document is decoded here.
+	data := &rest.UpdatePeopleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		s.handleError(w, r, rest.ErrPreconditionFailed)
+		return
+	}
+
+	people, err := usecases.PatchPeopleByID(r.Context(), s.repo, id, dto.PatchPeople(*data), version)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	setETag(w, people)
+	if err := render.Render(w, r, rest.NewPeopleResponse(*people)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
 	}
 }
 
@@ -166,10 +316,30 @@ func (s *Server) deletePeople(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = usecases.DeletePeopleByID(context.Background(), s.repo, id)
+	hard, err := boolParam(r, "hard")
 	if err != nil {
-		s.logger.Error("internal serever error", logging.Err(err))
-		s.handleError(w, r, rest.ErrInternalServerError)
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		s.handleError(w, r, rest.ErrPreconditionFailed)
+		return
+	}
+
+	if hard {
+		if !s.isAdmin(r) {
+			s.handleError(w, r, rest.ErrForbidden)
+			return
+		}
+		err = usecases.PurgePeopleByID(r.Context(), s.repo, id, version)
+	} else {
+		err = usecases.DeletePeopleByID(r.Context(), s.repo, id, version)
+	}
+	if err != nil {
+		s.handleRepoError(w, r, err)
 		return
 	}
 
@@ -177,3 +347,160 @@ func (s *Server) deletePeople(w http.ResponseWriter, r *http.Request) {
 		s.logger.Error("failed to write response", logging.Err(err))
 	}
 }
+
+func (s *Server) restorePeople(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	people, err := usecases.RestorePeopleByID(r.Context(), s.repo, id)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	setETag(w, people)
+	if err := render.Render(w, r, rest.NewPeopleResponse(*people)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+func (s *Server) batchPeoples(w http.ResponseWriter, r *http.Request) {
+	data := &rest.BatchRequest{}
+	if err := render.Bind(r, data); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	atomic := data.Mode == rest.BatchModeAtomic
+	resp := &rest.BatchResponse{Results: make([]rest.BatchItemResponse, len(data.Operations))}
+
+	var (
+		items   []dto.BatchItem
+		indexes []int
+		invalid bool
+	)
+	// Operations are applied grouped by kind, so one id may only be
+	// touched once per batch; its later operations fail.
+	seen := make(map[uuid.UUID]bool)
+	for i, op := range data.Operations {
+		resp.Results[i] = rest.BatchItemResponse{Index: i, Op: op.Op, ID: op.ID}
+
+		err := op.Validate()
+		if err == nil && op.ID != nil {
+			if seen[*op.ID] {
+				err = fmt.Errorf("id %s appears more than once", op.ID)
+			}
+			seen[*op.ID] = true
+		}
+		if err != nil {
+			var fields *dto.ValidationError
+			if errors.As(err, &fields) {
+				resp.Results[i].Status = http.StatusUnprocessableEntity
+				resp.Results[i].Error = "validation failed"
+				resp.Results[i].Fields = fields.Fields
+			} else {
+				resp.Results[i].Status = http.StatusBadRequest
+				resp.Results[i].Error = err.Error()
+			}
+			invalid = true
+			continue
+		}
+
+		item := dto.BatchItem{Op: op.Op}
+		if op.ID != nil {
+			item.ID = *op.ID
+		}
+		if op.Version != nil {
+			item.Version = *op.Version
+		}
+		if op.Data != nil {
+			item.People = dto.CreatePeople(*op.Data)
+		}
+		items = append(items, item)
+		indexes = append(indexes, i)
+	}
+
+	var results []dto.BatchResult
+	if atomic && invalid {
+		results = make([]dto.BatchResult, len(items))
+		for i := range results {
+			results[i].Err = dto.ErrBatchAborted
+		}
+	} else if len(items) > 0 {
+		var err error
+		results, err = usecases.BatchPeople(r.Context(), s.repo, items, atomic)
+		if err != nil {
+			s.logger.Error("internal server error", logging.Err(err))
+			s.handleError(w, r, rest.ErrInternalServerError)
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if invalid {
+		status = http.StatusMultiStatus
+	}
+	for n, result := range results {
+		item := &resp.Results[indexes[n]]
+		switch {
+		case result.Err == nil:
+			item.Status = http.StatusOK
+			if item.Op == dto.BatchCreate {
+				id := result.ID
+				item.Status = http.StatusCreated
+				item.ID = &id
+			}
+			if result.People != nil {
+				item.Data = rest.NewPeopleResponse(*result.People)
+			}
+			continue
+		case errors.Is(result.Err, sql.ErrNoRows):
+			item.Status = http.StatusNotFound
+		case errors.Is(result.Err, dto.ErrVersionConflict):
+			item.Status = http.StatusPreconditionFailed
+		case errors.Is(result.Err, dto.ErrBatchAborted):
+			item.Status = http.StatusFailedDependency
+		default:
+			s.logger.Error("batch item failed", logging.Err(result.Err))
+			item.Status = http.StatusInternalServerError
+		}
+		item.Error = http.StatusText(item.Status)
+		status = http.StatusMultiStatus
+	}
+
+	render.Status(r, status)
+	if err := render.Render(w, r, resp); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+// isAdmin reports whether the request carries the configured admin token
+// as a bearer token. Admin operations are disabled without a token.
+func (s *Server) isAdmin(r *http.Request) bool {
+	if s.cfg.adminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.adminToken)) == 1
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return b, nil
+}
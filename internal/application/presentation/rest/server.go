@@ -7,11 +7,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/config/rest_config"
+	db "github.com/Dmitrij-Kochetov/peoples/internal/adapter/database/repo"
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/http-server/middleware/validator"
+	internal "github.com/Dmitrij-Kochetov/peoples/internal/adapter/kafka"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
 	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/repo"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/events"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/outbox"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/webhook"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
@@ -21,11 +28,19 @@ type serverCfg struct {
 	addr        string
 	timeout     time.Duration
 	idleTimeout time.Duration
+	adminToken  string
 }
 
 type Server struct {
 	logger    *slog.Logger
 	repo      *repo.PeopleRepo
+	producer  *internal.Producer
+	relay     *outbox.Relay
+	events    *events.Hub
+	spec      *openapi3.T
+	validator validator.Handler
+	webhooks  *db.DbWebhookRepo
+	worker    *webhook.Worker
 	router    *chi.Mux
 	cfg       serverCfg
 	doneChan  chan struct{}
@@ -50,11 +65,63 @@ func NewServerFromConfig(cfg rest_config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to ping redis %w", err)
 	}
 
-	repos := repo.NewPeopleRepo(dbConn, client, cfg.Redis.Timeout)
+	repos := repo.NewPeopleRepo(logger, dbConn, client, cfg.Redis.Timeout)
+
+	spec, err := loadSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	var validate validator.Handler
+	if cfg.Server.ValidateRequests {
+		if validate, err = validator.New(logger, spec); err != nil {
+			return nil, fmt.Errorf("failed to create request validator %w", err)
+		}
+	}
+
+	producer, err := internal.NewKafkaProducer(cfg.Kafka.Address, cfg.Kafka.OutboxTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer %w", err)
+	}
+
+	webhooks := db.NewDbWebhookRepo(dbConn)
+	worker, err := webhook.NewWorker(logger,
+		webhooks,
+		webhook.NewSender(webhook.NewClient(cfg.Webhook.Timeout)),
+		webhook.Policy{
+			MaxAttempts:  cfg.Webhook.MaxAttempts,
+			BackoffBase:  cfg.Webhook.BackoffBase,
+			BackoffMax:   cfg.Webhook.BackoffMax,
+			DisableAfter: cfg.Webhook.DisableAfter,
+		},
+		cfg.Webhook.Interval,
+		cfg.Webhook.BatchSize,
+		2*cfg.Webhook.Timeout,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook worker %w", err)
+	}
+
+	relay := outbox.NewRelay(logger,
+		db.NewDbPeopleRepo(dbConn),
+		cfg.Outbox.Interval,
+		cfg.Outbox.BatchSize,
+		cfg.Outbox.Lease,
+		outbox.NewKafkaPublisher(producer),
+		outbox.NewRedisPublisher(client),
+		webhook.NewPublisher(webhooks),
+	)
 
 	return &Server{
 		logger:    logger,
 		repo:      repos,
+		producer:  producer,
+		relay:     relay,
+		events:    events.NewHub(logger, client),
+		spec:      spec,
+		validator: validate,
+		webhooks:  webhooks,
+		worker:    worker,
 		router:    chi.NewRouter(),
 		doneChan:  make(chan struct{}),
 		closeChan: make(chan struct{}),
@@ -62,12 +129,16 @@ func NewServerFromConfig(cfg rest_config.Config) (*Server, error) {
 			addr:        cfg.Server.Address,
 			timeout:     cfg.Server.Timeout,
 			idleTimeout: cfg.Server.IdleTimeout,
+			adminToken:  cfg.Server.AdminToken,
 		},
 	}, nil
 }
 
 func (s *Server) GetHttp() *http.Server {
 	s.setupRoutes()
+	s.relay.Run()
+	s.events.Run()
+	s.worker.Run()
 	srv := http.Server{
 		Addr:         s.cfg.addr,
 		Handler:      s.router,
@@ -75,6 +146,12 @@ func (s *Server) GetHttp() *http.Server {
 		ReadTimeout:  s.cfg.timeout,
 		WriteTimeout: s.cfg.timeout,
 	}
+	// Event streams never finish by themselves.
+	srv.RegisterOnShutdown(func() {
+		if err := s.events.Close(); err != nil {
+			s.logger.Error("failed to close event hub", logging.Err(err))
+		}
+	})
 
 	return &srv
 }
@@ -83,6 +160,13 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down")
 	close(s.closeChan)
 
+	if err := s.relay.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := s.worker.Shutdown(ctx); err != nil {
+		return err
+	}
+
 	for {
 		select {
 		case <-s.closeChan:
@@ -94,6 +178,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 }
 
 func (s *Server) Close(ctx context.Context) error {
+	s.producer.Producer.Close()
 	if err := s.repo.Close(ctx); err != nil {
 		return err
 	}
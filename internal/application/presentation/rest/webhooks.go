@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/application/usecases"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/rest"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	data := &rest.PageRequest{}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	page, err := usecases.GetAllWebhooks(r.Context(), s.webhooks, data.Limit, data.Offset)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if err := render.Render(w, r, rest.NewListWebhookResponse(page, *data)); err != nil {
+		s.logger.Error("failed to render", logging.Err(err))
+	}
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	hook, err := usecases.GetWebhookByID(r.Context(), s.webhooks, id)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	if err := render.Render(w, r, rest.NewWebhookResponse(*hook)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	data := &rest.WebhookRequest{}
+	if err := render.Bind(r, data); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	hook, err := usecases.CreateWebhook(r.Context(), s.webhooks, data.Webhook())
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	// The secret is shown once, so that a generated one can be stored.
+	resp := rest.NewWebhookResponse(*hook)
+	resp.Secret = hook.Secret
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/webhooks/%s", hook.ID))
+	render.Status(r, http.StatusCreated)
+	if err := render.Render(w, r, resp); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	data := &rest.WebhookRequest{}
+	if err := render.Bind(r, data); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	hook, err := usecases.UpdateWebhookByID(r.Context(), s.webhooks, id, data.Webhook())
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	if err := render.Render(w, r, rest.NewWebhookResponse(*hook)); err != nil {
+		s.logger.Error("error rendering", logging.Err(err))
+	}
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	if err := usecases.DeleteWebhookByID(r.Context(), s.webhooks, id); err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	if _, err = w.Write(nil); err != nil {
+		s.logger.Error("failed to write response", logging.Err(err))
+	}
+}
+
+func (s *Server) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.Error("error parsing", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	data := &rest.PageRequest{}
+	if err := data.Bind(r); err != nil {
+		s.logger.Error("bad request", logging.Err(err))
+		s.handleError(w, r, rest.ErrBadRequest)
+		return
+	}
+
+	page, err := usecases.GetWebhookDeliveries(r.Context(), s.webhooks, id, data.Limit, data.Offset)
+	if err != nil {
+		s.handleRepoError(w, r, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if err := render.Render(w, r, rest.NewListWebhookDeliveryResponse(page, *data)); err != nil {
+		s.logger.Error("failed to render", logging.Err(err))
+	}
+}
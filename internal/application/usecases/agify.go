@@ -1,97 +1,127 @@
 package usecases
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"slices"
+	"strings"
 
 	db "github.com/Dmitrij-Kochetov/peoples/internal/adapter/database/repo"
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto/kafka"
+	"golang.org/x/sync/errgroup"
 )
 
-type AgifyResponse struct {
-	Age int `json:"age"`
+// BatchEnricher tells the probable age, gender and nationality of many
+// first names at once, answering in the order of the names. Gender and
+// nationality are empty for a name it does not know.
+type BatchEnricher interface {
+	Ages(ctx context.Context, names []string) ([]int, error)
+	Genders(ctx context.Context, names []string) ([]string, error)
+	Nationalities(ctx context.Context, names []string) ([]string, error)
 }
 
-type GenderizeResponse struct {
-	Gender string `json:"gender"`
+// AgifyCache remembers what the enricher told about a normalised name,
+// including that it did not know it.
+type AgifyCache interface {
+	Get(ctx context.Context, name string) (info dto.AgifyInfo, found bool, err error)
+	Set(ctx context.Context, name string, info dto.AgifyInfo) error
 }
 
-type NationalizeResponse struct {
-	Country []nation `json:"country"`
-}
-
-type nation struct {
-	CountryId string `json:"country_id"`
-}
+var ErrUnknownName = errors.New("cannot get existing gender, possibly name is wrong")
 
-type Responses interface {
-	AgifyResponse | GenderizeResponse | NationalizeResponse
-}
+type AgifyInfo = dto.AgifyInfo
 
-type AgifyInfo struct {
-	Age    int
-	Sex    string
-	Nation string
+// normalizeName makes differently written forms of a name share a cache
+// entry.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
 }
 
-func doRequest[R Responses](url, name string, response R) (R, error) {
-	resp, err := http.Get(url + name)
-	if err != nil {
-		return *new(R), err
+// AgifyPeoples looks names up, answering from cache when it can and
+// asking the enricher once per lookup about the names missing from it.
+// The cache is best effort: when it fails the enricher is asked anyway.
+// The result of a name the enricher does not know is not Known, and is
+// cached as such. The first failing lookup fails every name.
+func AgifyPeoples(ctx context.Context, cache AgifyCache, enricher BatchEnricher, names []string) ([]AgifyInfo, error) {
+	infos := make([]AgifyInfo, len(names))
+	found := make(map[string]AgifyInfo, len(names))
+	var missing []string
+
+	for _, name := range names {
+		key := normalizeName(name)
+		if _, ok := found[key]; ok || slices.Contains(missing, key) {
+			continue
+		}
+		if info, ok, err := cache.Get(ctx, key); err == nil && ok {
+			found[key] = info
+			continue
+		}
+		missing = append(missing, key)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return *new(R), err
-	}
+	if len(missing) > 0 {
+		var ages []int
+		var sexes, nations []string
+		g, gctx := errgroup.WithContext(ctx)
 
-	if err := resp.Body.Close(); err != nil {
-		return *new(R), err
+		g.Go(func() (err error) {
+			if ages, err = enricher.Ages(gctx, missing); err != nil {
+				return fmt.Errorf("age: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() (err error) {
+			if sexes, err = enricher.Genders(gctx, missing); err != nil {
+				return fmt.Errorf("gender: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() (err error) {
+			if nations, err = enricher.Nationalities(gctx, missing); err != nil {
+				return fmt.Errorf("nationality: %w", err)
+			}
+			return nil
+		})
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+		if len(ages) != len(missing) || len(sexes) != len(missing) || len(nations) != len(missing) {
+			return nil, fmt.Errorf("enricher answered %d names with %d ages, %d genders and %d nationalities",
+				len(missing), len(ages), len(sexes), len(nations))
+		}
+
+		for i, key := range missing {
+			info := AgifyInfo{Age: ages[i], Sex: sexes[i], Nation: nations[i]}
+			if !info.Known() {
+				info = AgifyInfo{}
+			}
+			found[key] = info
+			_ = cache.Set(ctx, key, info)
+		}
 	}
 
-	if err := json.Unmarshal(body, &response); err != nil {
-		return *new(R), err
+	for i, name := range names {
+		infos[i] = found[normalizeName(name)]
 	}
-	return response, nil
+	return infos, nil
 }
 
-func AgifyPeople(name string) (AgifyInfo, error) {
-	var urls = []string{"https://api.agify.io/?name=", "https://api.genderize.io/?name=", "https://api.nationalize.io/?name="}
-
-	age, err := doRequest(urls[0], name, AgifyResponse{})
-	if err != nil {
-		return AgifyInfo{}, err
-	}
-	sex, err := doRequest(urls[1], name, GenderizeResponse{})
-	if err != nil {
-		return AgifyInfo{}, err
-	}
-	if sex.Gender == "" {
-		return AgifyInfo{}, fmt.Errorf("cannot get existing gender, possibly name is wrong")
+// CreateAgifiedPeople creates a person from a name and what the providers
+// told about it. It returns a *dto.ValidationError if the name is not
+// valid. Provider fields that are not valid are left out instead, and
+// ErrUnknownName is returned if that leaves the sex unknown.
+func CreateAgifiedPeople(ctx context.Context, db *db.DbPeopleRepo, name kafka.PeopleName, info AgifyInfo) error {
+	if err := name.Validate(); err != nil {
+		return err
 	}
-
-	nation, err := doRequest(urls[2], name, NationalizeResponse{})
-	if err != nil {
-		return AgifyInfo{}, err
+	info = info.Valid()
+	if !info.Known() {
+		return ErrUnknownName
 	}
 
-	return AgifyInfo{
-		Age: age.Age,
-		Sex: sex.Gender,
-		Nation: func() string {
-			if len(nation.Country) == 0 {
-				return ""
-			}
-			return nation.Country[0].CountryId
-		}(),
-	}, nil
-}
-
-func CreateAgifiedPeople(db *db.DbPeopleRepo, name kafka.PeopleName, info AgifyInfo) error {
-	return db.Create(dto.CreatePeople{
+	_, err := db.CreateEnriched(ctx, dto.CreatePeople{
 		FirstName:  *name.FirstName,
 		LastName:   *name.LastName,
 		Patronymic: name.Patronymic,
@@ -99,4 +129,5 @@ func CreateAgifiedPeople(db *db.DbPeopleRepo, name kafka.PeopleName, info AgifyI
 		Sex:        info.Sex,
 		Nation:     info.Nation,
 	})
+	return err
 }
@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+type fakeCache struct {
+	infos map[string]dto.AgifyInfo
+	err   error
+}
+
+func (c *fakeCache) Get(_ context.Context, name string) (dto.AgifyInfo, bool, error) {
+	if c.err != nil {
+		return dto.AgifyInfo{}, false, c.err
+	}
+	info, ok := c.infos[name]
+	return info, ok, nil
+}
+
+func (c *fakeCache) Set(_ context.Context, name string, info dto.AgifyInfo) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.infos[name] = info
+	return nil
+}
+
+// fakeEnricher knows "ivan" and "anna", and records the names of every
+// lookup.
+type fakeEnricher struct {
+	mu    sync.Mutex
+	asked [][]string
+	err   error
+	short bool
+}
+
+var known = map[string]dto.AgifyInfo{
+	"ivan": {Age: 42, Sex: dto.SexMale, Nation: "RU"},
+	"anna": {Age: 30, Sex: dto.SexFemale, Nation: "IE"},
+}
+
+func lookup[T any](e *fakeEnricher, names []string, get func(dto.AgifyInfo) T) ([]T, error) {
+	e.mu.Lock()
+	e.asked = append(e.asked, names)
+	e.mu.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	res := make([]T, len(names))
+	for i, name := range names {
+		res[i] = get(known[name])
+	}
+	if e.short {
+		res = res[1:]
+	}
+	return res, nil
+}
+
+func (e *fakeEnricher) Ages(_ context.Context, names []string) ([]int, error) {
+	return lookup(e, names, func(i dto.AgifyInfo) int { return i.Age })
+}
+
+func (e *fakeEnricher) Genders(_ context.Context, names []string) ([]string, error) {
+	return lookup(e, names, func(i dto.AgifyInfo) string { return i.Sex })
+}
+
+func (e *fakeEnricher) Nationalities(_ context.Context, names []string) ([]string, error) {
+	return lookup(e, names, func(i dto.AgifyInfo) string { return i.Nation })
+}
+
+func TestAgifyPeoples(t *testing.T) {
+	cached := dto.AgifyInfo{Age: 50, Sex: dto.SexFemale, Nation: "BY"}
+	cache := &fakeCache{infos: map[string]dto.AgifyInfo{"olga": cached}}
+	enricher := &fakeEnricher{}
+
+	infos, err := AgifyPeoples(context.Background(), cache, enricher, []string{"Ivan", "olga", " IVAN ", "Xyzzy", "anna"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []dto.AgifyInfo{known["ivan"], cached, known["ivan"], {}, known["anna"]}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("AgifyPeoples() = %+v, want %+v", infos, want)
+	}
+
+	// Each lookup is asked once about the normalised names missing from
+	// cache.
+	if len(enricher.asked) != 3 {
+		t.Fatalf("got %d lookups, want 3", len(enricher.asked))
+	}
+	for _, names := range enricher.asked {
+		if !reflect.DeepEqual(names, []string{"ivan", "xyzzy", "anna"}) {
+			t.Errorf("asked about %q", names)
+		}
+	}
+
+	// The unknown name is cached too, so it is not asked about again.
+	if info, ok := cache.infos["xyzzy"]; !ok || info.Known() {
+		t.Errorf("cached xyzzy = %+v, %v, want an unknown name", info, ok)
+	}
+	if cache.infos["ivan"] != known["ivan"] {
+		t.Errorf("cached ivan = %+v", cache.infos["ivan"])
+	}
+
+	enricher.asked = nil
+	if _, err := AgifyPeoples(context.Background(), cache, enricher, []string{"Xyzzy", "Ivan"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(enricher.asked) != 0 {
+		t.Errorf("asked about cached names: %q", enricher.asked)
+	}
+}
+
+func TestAgifyPeoplesCacheFailure(t *testing.T) {
+	cache := &fakeCache{err: errors.New("connection refused")}
+	enricher := &fakeEnricher{}
+
+	// The cache is best effort.
+	infos, err := AgifyPeoples(context.Background(), cache, enricher, []string{"Ivan"})
+	if err != nil || len(infos) != 1 || infos[0] != known["ivan"] {
+		t.Errorf("AgifyPeoples() = %+v, %v", infos, err)
+	}
+}
+
+func TestAgifyPeoplesEnricherFailure(t *testing.T) {
+	enricher := &fakeEnricher{err: errors.New("gateway timeout")}
+	if _, err := AgifyPeoples(context.Background(), &fakeCache{infos: map[string]dto.AgifyInfo{}}, enricher, []string{"Ivan"}); !errors.Is(err, enricher.err) {
+		t.Errorf("AgifyPeoples() error = %v, want %v", err, enricher.err)
+	}
+
+	enricher = &fakeEnricher{short: true}
+	if _, err := AgifyPeoples(context.Background(), &fakeCache{infos: map[string]dto.AgifyInfo{}}, enricher, []string{"Ivan", "Anna"}); err == nil {
+		t.Error("AgifyPeoples() accepted fewer answers than names")
+	}
+}
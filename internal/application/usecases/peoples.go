@@ -2,34 +2,115 @@ package usecases
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
 	"github.com/google/uuid"
 )
 
 type IPeopleRepo interface {
 	GetByID(context.Context, uuid.UUID) (*dto.People, error)
-	GetAllByFilter(context.Context, dto.Filter) (*dto.Peoples, error)
-	Create(context.Context, dto.CreatePeople) error
-	Update(context.Context, dto.People) error
-	DeleteByID(context.Context, uuid.UUID) error
+	GetByIDAsOf(context.Context, uuid.UUID, time.Time) (*dto.People, error)
+	GetAllByFilter(context.Context, dto.Filter) (*dto.PeoplesPage, error)
+	Search(context.Context, string, int) (*dto.SearchHits, error)
+	Create(context.Context, dto.CreatePeople) (*dto.People, error)
+	Update(context.Context, dto.People) (*dto.People, error)
+	Patch(context.Context, uuid.UUID, dto.PatchPeople, int) (*dto.People, error)
+	DeleteByID(context.Context, uuid.UUID, int) error
+	Restore(context.Context, uuid.UUID) (*dto.People, error)
+	Purge(context.Context, uuid.UUID, int) error
+	Batch(context.Context, []dto.BatchItem, bool) ([]dto.BatchResult, error)
+	GetHistory(context.Context, uuid.UUID, int, int) (*dto.HistoryPage, error)
+	GetEventsAfter(context.Context, int64, int) ([]dto.Event, error)
+}
+
+// GetPeopleByID hides soft-deleted people unless includeDeleted is set.
+func GetPeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID, includeDeleted bool) (*dto.People, error) {
+	people, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return hideDeleted(people, includeDeleted)
+}
+
+// GetPeopleByIDAsOf returns a person as it was at asOf, hiding it if it was
+// soft-deleted then unless includeDeleted is set.
+func GetPeopleByIDAsOf(ctx context.Context, repo IPeopleRepo, id uuid.UUID, asOf time.Time, includeDeleted bool) (*dto.People, error) {
+	people, err := repo.GetByIDAsOf(ctx, id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return hideDeleted(people, includeDeleted)
 }
 
-func GetPeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID) (*dto.People, error) {
-	return repo.GetByID(ctx, id)
+func hideDeleted(people *dto.People, includeDeleted bool) (*dto.People, error) {
+	if people.Deleted && !includeDeleted {
+		return nil, sql.ErrNoRows
+	}
+	return people, nil
 }
 
-func GetAllPeopleByFilter(ctx context.Context, repo IPeopleRepo, filter dto.Filter) (*dto.Peoples, error) {
+func GetAllPeopleByFilter(ctx context.Context, repo IPeopleRepo, filter dto.Filter) (*dto.PeoplesPage, error) {
 	return repo.GetAllByFilter(ctx, filter)
 }
 
-func CreatePeople(ctx context.Context, repo IPeopleRepo, people dto.CreatePeople) error {
+func SearchPeople(ctx context.Context, repo IPeopleRepo, query string, limit int) (*dto.SearchHits, error) {
+	return repo.Search(ctx, query, limit)
+}
+
+// CreatePeople creates a person. It returns a *dto.ValidationError if the
+// person is not valid.
+func CreatePeople(ctx context.Context, repo IPeopleRepo, people dto.CreatePeople) (*dto.People, error) {
+	if err := people.Validate(); err != nil {
+		return nil, err
+	}
 	return repo.Create(ctx, people)
 }
 
-func UpdatePeopleByID(ctx context.Context, repo IPeopleRepo, people dto.People) error {
+// UpdatePeopleByID replaces a person. A non-zero people.Version is the
+// version the caller expects to overwrite. It returns a
+// *dto.ValidationError if the person is not valid.
+func UpdatePeopleByID(ctx context.Context, repo IPeopleRepo, people dto.People) (*dto.People, error) {
+	err := dto.CreatePeople{
+		FirstName:  people.FirstName,
+		LastName:   people.LastName,
+		Patronymic: people.Patronymic,
+		Age:        people.Age,
+		Sex:        people.Sex,
+		Nation:     people.Nation,
+	}.Validate()
+	if err != nil {
+		return nil, err
+	}
 	return repo.Update(ctx, people)
 }
 
-func DeletePeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID) error {
-	return repo.DeleteByID(ctx, id)
+// PatchPeopleByID applies a merge patch to a person. It returns a
+// *dto.ValidationError if the patch sets an invalid value.
+func PatchPeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID, patch dto.PatchPeople, version int) (*dto.People, error) {
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	return repo.Patch(ctx, id, patch, version)
+}
+
+func DeletePeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID, version int) error {
+	return repo.DeleteByID(ctx, id, version)
+}
+
+func RestorePeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID) (*dto.People, error) {
+	return repo.Restore(ctx, id)
+}
+
+func PurgePeopleByID(ctx context.Context, repo IPeopleRepo, id uuid.UUID, version int) error {
+	return repo.Purge(ctx, id, version)
+}
+
+func BatchPeople(ctx context.Context, repo IPeopleRepo, items []dto.BatchItem, atomic bool) ([]dto.BatchResult, error) {
+	return repo.Batch(ctx, items, atomic)
+}
+
+func GetPeopleHistory(ctx context.Context, repo IPeopleRepo, id uuid.UUID, limit, offset int) (*dto.HistoryPage, error) {
+	return repo.GetHistory(ctx, id, limit, offset)
 }
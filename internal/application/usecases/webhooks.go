@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+type IWebhookRepo interface {
+	GetByID(context.Context, uuid.UUID) (*dto.Webhook, error)
+	GetAll(context.Context, int, int) (*dto.WebhooksPage, error)
+	Create(context.Context, dto.Webhook) (*dto.Webhook, error)
+	Update(context.Context, dto.Webhook) (*dto.Webhook, error)
+	DeleteByID(context.Context, uuid.UUID) error
+	GetDeliveries(context.Context, uuid.UUID, int, int) (*dto.WebhookDeliveriesPage, error)
+}
+
+func GetWebhookByID(ctx context.Context, repo IWebhookRepo, id uuid.UUID) (*dto.Webhook, error) {
+	return repo.GetByID(ctx, id)
+}
+
+func GetAllWebhooks(ctx context.Context, repo IWebhookRepo, limit, offset int) (*dto.WebhooksPage, error) {
+	return repo.GetAll(ctx, limit, offset)
+}
+
+// CreateWebhook registers a webhook, generating its secret if none is
+// given.
+func CreateWebhook(ctx context.Context, repo IWebhookRepo, hook dto.Webhook) (*dto.Webhook, error) {
+	if hook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = secret
+	}
+	return repo.Create(ctx, hook)
+}
+
+// UpdateWebhookByID replaces a webhook, keeping its secret if none is
+// given.
+func UpdateWebhookByID(ctx context.Context, repo IWebhookRepo, id uuid.UUID, hook dto.Webhook) (*dto.Webhook, error) {
+	hook.ID = id
+	if hook.Secret == "" {
+		current, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = current.Secret
+	}
+	return repo.Update(ctx, hook)
+}
+
+func DeleteWebhookByID(ctx context.Context, repo IWebhookRepo, id uuid.UUID) error {
+	return repo.DeleteByID(ctx, id)
+}
+
+func GetWebhookDeliveries(ctx context.Context, repo IWebhookRepo, id uuid.UUID, limit, offset int) (*dto.WebhookDeliveriesPage, error) {
+	if _, err := repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return repo.GetDeliveries(ctx, id, limit, offset)
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
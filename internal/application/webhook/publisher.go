@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// Queue holds the webhooks and their pending deliveries, as DbWebhookRepo
+// does.
+type Queue interface {
+	GetEnabled(ctx context.Context) ([]dto.Webhook, error)
+	Enqueue(ctx context.Context, deliveries []dto.WebhookDelivery) error
+}
+
+// Publisher queues outbox events for the webhooks accepting them. It is an
+// outbox.Publisher; the actual requests are made by the Worker, so a slow
+// endpoint never holds the outbox up.
+type Publisher struct {
+	repo Queue
+}
+
+func NewPublisher(repo Queue) *Publisher {
+	return &Publisher{repo: repo}
+}
+
+func (p *Publisher) Publish(ctx context.Context, events []dto.Event) error {
+	hooks, err := p.repo.GetEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []dto.WebhookDelivery
+	for _, event := range events {
+		for _, hook := range hooks {
+			if hook.Accepts(event) {
+				deliveries = append(deliveries, dto.WebhookDelivery{WebhookID: hook.ID, Event: event})
+			}
+		}
+	}
+
+	return p.repo.Enqueue(ctx, deliveries)
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+type fakeQueue struct {
+	hooks    []dto.Webhook
+	enqueued []dto.WebhookDelivery
+}
+
+func (q *fakeQueue) GetEnabled(context.Context) ([]dto.Webhook, error) {
+	return q.hooks, nil
+}
+
+func (q *fakeQueue) Enqueue(_ context.Context, deliveries []dto.WebhookDelivery) error {
+	q.enqueued = append(q.enqueued, deliveries...)
+	return nil
+}
+
+func TestPublisherPublish(t *testing.T) {
+	all := dto.Webhook{ID: uuid.New(), Enabled: true}
+	deletes := dto.Webhook{ID: uuid.New(), Enabled: true, EventTypes: []string{dto.EventDeleted}}
+	queue := &fakeQueue{hooks: []dto.Webhook{all, deletes}}
+
+	err := NewPublisher(queue).Publish(context.Background(), []dto.Event{
+		{ID: 1, Type: dto.EventCreated, People: &dto.People{}},
+		{ID: 2, Type: dto.EventDeleted, People: &dto.People{Deleted: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type delivery struct {
+		hook  uuid.UUID
+		event int64
+	}
+	var got []delivery
+	for _, d := range queue.enqueued {
+		got = append(got, delivery{d.WebhookID, d.Event.ID})
+	}
+	want := []delivery{{all.ID, 1}, {all.ID, 2}, {deletes.ID, 2}}
+	if len(got) != len(want) {
+		t.Fatalf("enqueued %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("enqueued[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
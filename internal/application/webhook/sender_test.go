@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+func TestSenderSend(t *testing.T) {
+	var (
+		gotHeader http.Header
+		gotBody   []byte
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(receiver.Client())
+	sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	d := dto.WebhookDelivery{
+		ID:     42,
+		URL:    receiver.URL,
+		Secret: "s3cret",
+		Event: dto.Event{
+			ID:       7,
+			Type:     dto.EventCreated,
+			PeopleID: uuid.New(),
+			People:   &dto.People{FirstName: "Ivan"},
+		},
+	}
+
+	status, err := sender.Send(context.Background(), d)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", status, http.StatusNoContent)
+	}
+
+	if got := gotHeader.Get(HeaderEvent); got != dto.EventCreated {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, dto.EventCreated)
+	}
+	if got := gotHeader.Get(HeaderDelivery); got != "42" {
+		t.Errorf("%s = %q, want 42", HeaderDelivery, got)
+	}
+	timestamp, err := strconv.ParseInt(gotHeader.Get(HeaderTimestamp), 10, 64)
+	if err != nil || timestamp != 1700000000 {
+		t.Errorf("%s = %q", HeaderTimestamp, gotHeader.Get(HeaderTimestamp))
+	}
+	if got, want := gotHeader.Get(HeaderSignature), Sign("s3cret", timestamp, gotBody); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+
+	var event dto.Event
+	if err := json.Unmarshal(gotBody, &event); err != nil {
+		t.Fatalf("body is not an event: %v", err)
+	}
+	if event.ID != 7 || event.People.FirstName != "Ivan" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestSenderSendNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := NewSender(receiver.Client()).Send(context.Background(), dto.WebhookDelivery{
+		URL:   receiver.URL,
+		Event: dto.Event{People: &dto.People{}},
+	})
+	if err == nil {
+		t.Fatal("Send() error = nil, want an error")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestNewClientRefusesPrivateAddr(t *testing.T) {
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	// The receiver listens on loopback, like an internal service would.
+	_, err := NewSender(NewClient(time.Second)).Send(context.Background(), dto.WebhookDelivery{
+		URL:   receiver.URL,
+		Event: dto.Event{People: &dto.People{}},
+	})
+	if !errors.Is(err, errPrivateAddr) {
+		t.Errorf("Send() error = %v, want %v", err, errPrivateAddr)
+	}
+	if called {
+		t.Error("the private receiver was called")
+	}
+}
+
+func TestNewClientDoesNotFollowRedirects(t *testing.T) {
+	if err := NewClient(time.Second).CheckRedirect(nil, nil); err != http.ErrUseLastResponse {
+		t.Errorf("CheckRedirect() = %v, want http.ErrUseLastResponse", err)
+	}
+}
+
+func TestSenderSendRedirect(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	client := receiver.Client()
+	client.CheckRedirect = NewClient(time.Second).CheckRedirect
+	status, err := NewSender(client).Send(context.Background(), dto.WebhookDelivery{
+		URL:   receiver.URL,
+		Event: dto.Event{People: &dto.People{}},
+	})
+	if err == nil || status != http.StatusFound {
+		t.Errorf("Send() = %d, %v, want a failed %d", status, err, http.StatusFound)
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	p := Policy{BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		4: 8 * time.Second,
+		5: 10 * time.Second,
+		9: 10 * time.Second,
+	} {
+		if got := p.Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/adapter/logging"
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
+// Policy governs the retries of failed deliveries.
+type Policy struct {
+	// MaxAttempts is how often a delivery is tried before it fails.
+	MaxAttempts int
+	// BackoffBase is the delay after the first failed attempt. It doubles
+	// with every further attempt up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// DisableAfter is how many consecutive failed attempts disable a
+	// webhook.
+	DisableAfter int
+}
+
+// Backoff returns the delay before the attempt following the given number
+// of failed attempts.
+func (p Policy) Backoff(attempts int) time.Duration {
+	delay := p.BackoffBase
+	for i := 1; i < attempts && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, p.BackoffMax)
+}
+
+// Deliveries holds the queued deliveries, as DbWebhookRepo does.
+type Deliveries interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error)
+	// RecordAttempt stores the outcome of an attempt and tells whether it
+	// disabled the webhook.
+	RecordAttempt(ctx context.Context, d dto.WebhookDelivery, disableAfter int) (bool, error)
+}
+
+// Worker delivers the queued events to their webhooks.
+type Worker struct {
+	logger    *slog.Logger
+	repo      Deliveries
+	sender    *Sender
+	policy    Policy
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+	doneChan  chan struct{}
+	closeChan chan struct{}
+}
+
+// NewWorker returns a worker polling for due deliveries every interval.
+// A claimed delivery is not handed out again for lease, which must exceed
+// the request timeout of the sender.
+func NewWorker(logger *slog.Logger, repo Deliveries, sender *Sender, policy Policy, interval time.Duration, batchSize int, lease time.Duration) (*Worker, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("webhook interval must be positive")
+	}
+	// The worker drains while full batches come back, so an empty batch
+	// must not count as full.
+	if batchSize < 1 {
+		return nil, fmt.Errorf("webhook batch size must be positive")
+	}
+
+	return &Worker{
+		logger:    logger,
+		repo:      repo,
+		sender:    sender,
+		policy:    policy,
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     lease,
+		doneChan:  make(chan struct{}),
+		closeChan: make(chan struct{}),
+	}, nil
+}
+
+// Run delivers in the background until Shutdown is called.
+func (w *Worker) Run() {
+	go func() {
+		defer close(w.doneChan)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			for n := w.batchSize; n == w.batchSize; {
+				n = w.deliverDue(context.Background())
+			}
+
+			select {
+			case <-ticker.C:
+			case <-w.closeChan:
+				w.logger.Info("webhook worker stopped")
+				return
+			}
+		}
+	}()
+}
+
+// deliverDue attempts a batch of due deliveries concurrently and returns
+// its size.
+func (w *Worker) deliverDue(ctx context.Context) int {
+	due, err := w.repo.ClaimDue(ctx, w.batchSize, w.lease)
+	if err != nil {
+		w.logger.Error("failed to claim webhook deliveries", logging.Err(err))
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range due {
+		wg.Add(1)
+		go func(d dto.WebhookDelivery) {
+			defer wg.Done()
+			if err := w.Deliver(ctx, d); err != nil {
+				w.logger.Error("failed to record webhook delivery", logging.Err(err))
+			}
+		}(d)
+	}
+	wg.Wait()
+
+	return len(due)
+}
+
+// Deliver makes one attempt of the delivery and records its outcome.
+func (w *Worker) Deliver(ctx context.Context, d dto.WebhookDelivery) error {
+	status, err := w.sender.Send(ctx, d)
+
+	now := time.Now()
+	d.Attempts++
+	d.LastStatusCode = status
+	d.LastError = ""
+
+	switch {
+	case err == nil:
+		d.Status = dto.DeliveryDelivered
+		d.DeliveredAt = &now
+	case d.Attempts >= w.policy.MaxAttempts:
+		d.Status = dto.DeliveryFailed
+		d.LastError = err.Error()
+	default:
+		d.Status = dto.DeliveryPending
+		d.LastError = err.Error()
+		d.NextAttemptAt = now.Add(w.policy.Backoff(d.Attempts))
+	}
+
+	if err != nil {
+		w.logger.Warn("webhook delivery failed",
+			logging.Err(err),
+			slog.Int64("delivery_id", d.ID),
+			slog.String("webhook_id", d.WebhookID.String()),
+			slog.Int("attempts", d.Attempts),
+		)
+	}
+
+	disabled, err := w.repo.RecordAttempt(ctx, d, w.policy.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		w.logger.Warn("webhook disabled after consecutive failures",
+			slog.String("webhook_id", d.WebhookID.String()),
+			slog.Int("failures", w.policy.DisableAfter),
+		)
+	}
+	return nil
+}
+
+func (w *Worker) Shutdown(ctx context.Context) error {
+	close(w.closeChan)
+
+	select {
+	case <-w.doneChan:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("context canceled: %w", ctx.Err())
+	}
+}
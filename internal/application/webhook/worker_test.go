@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+// fakeDeliveries records the attempts and counts the consecutive failures
+// of a single webhook like DbWebhookRepo.
+type fakeDeliveries struct {
+	recorded []dto.WebhookDelivery
+	failures int
+	enabled  bool
+}
+
+func (f *fakeDeliveries) ClaimDue(context.Context, int, time.Duration) ([]dto.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeDeliveries) RecordAttempt(_ context.Context, d dto.WebhookDelivery, disableAfter int) (bool, error) {
+	f.recorded = append(f.recorded, d)
+	if d.Status == dto.DeliveryDelivered {
+		f.failures = 0
+		return false, nil
+	}
+	f.failures++
+	if f.enabled && f.failures >= disableAfter {
+		f.enabled = false
+		return true, nil
+	}
+	return false, nil
+}
+
+func newTestWorker(t *testing.T, repo Deliveries, client *http.Client) *Worker {
+	t.Helper()
+	w, err := NewWorker(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo,
+		NewSender(client),
+		Policy{MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour, DisableAfter: 4},
+		time.Second,
+		10,
+		time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWorkerDeliver(t *testing.T) {
+	status := http.StatusOK
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+		want     string
+	}{
+		{"delivered", http.StatusNoContent, 0, dto.DeliveryDelivered},
+		{"retried", http.StatusInternalServerError, 0, dto.DeliveryPending},
+		{"failed", http.StatusInternalServerError, 2, dto.DeliveryFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			repo := &fakeDeliveries{enabled: true}
+			w := newTestWorker(t, repo, receiver.Client())
+
+			before := time.Now()
+			err := w.Deliver(context.Background(), dto.WebhookDelivery{
+				ID:       1,
+				URL:      receiver.URL,
+				Attempts: tt.attempts,
+				Event:    dto.Event{People: &dto.People{}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(repo.recorded) != 1 {
+				t.Fatalf("recorded %d attempts, want 1", len(repo.recorded))
+			}
+			d := repo.recorded[0]
+			if d.Status != tt.want {
+				t.Errorf("Status = %q, want %q", d.Status, tt.want)
+			}
+			if d.Attempts != tt.attempts+1 || d.LastStatusCode != tt.status {
+				t.Errorf("Attempts = %d, LastStatusCode = %d", d.Attempts, d.LastStatusCode)
+			}
+			switch tt.want {
+			case dto.DeliveryDelivered:
+				if d.DeliveredAt == nil || d.LastError != "" {
+					t.Errorf("DeliveredAt = %v, LastError = %q", d.DeliveredAt, d.LastError)
+				}
+			case dto.DeliveryPending:
+				if d.NextAttemptAt.Before(before.Add(time.Minute)) || d.LastError == "" {
+					t.Errorf("NextAttemptAt = %v, LastError = %q", d.NextAttemptAt, d.LastError)
+				}
+			case dto.DeliveryFailed:
+				if d.DeliveredAt != nil || d.LastError == "" {
+					t.Errorf("DeliveredAt = %v, LastError = %q", d.DeliveredAt, d.LastError)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerDeliverDisables(t *testing.T) {
+	status := http.StatusBadGateway
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	repo := &fakeDeliveries{enabled: true}
+	w := newTestWorker(t, repo, receiver.Client())
+	deliver := func() {
+		if err := w.Deliver(context.Background(), dto.WebhookDelivery{
+			WebhookID: uuid.New(),
+			URL:       receiver.URL,
+			Event:     dto.Event{People: &dto.People{}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A delivery in between resets the consecutive failures.
+	for i := 0; i < 3; i++ {
+		deliver()
+	}
+	status = http.StatusOK
+	deliver()
+	status = http.StatusBadGateway
+	for i := 0; i < 3; i++ {
+		deliver()
+	}
+	if !repo.enabled {
+		t.Fatal("webhook disabled before DisableAfter consecutive failures")
+	}
+
+	deliver()
+	if repo.enabled {
+		t.Error("webhook still enabled after DisableAfter consecutive failures")
+	}
+}
+
+func TestNewWorkerValidates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, args := range map[string]struct {
+		interval  time.Duration
+		batchSize int
+	}{
+		"zero interval":   {0, 10},
+		"zero batch size": {time.Second, 0},
+	} {
+		if _, err := NewWorker(logger, &fakeDeliveries{}, nil, Policy{}, args.interval, args.batchSize, time.Minute); err == nil {
+			t.Errorf("%s: NewWorker() error = nil", name)
+		}
+	}
+}
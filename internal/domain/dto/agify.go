@@ -0,0 +1,35 @@
+package dto
+
+// AgifyInfo is what the enrichment providers tell about a first name. Sex
+// is empty for a name they do not know.
+type AgifyInfo struct {
+	Age    int    `json:"age"`
+	Sex    string `json:"sex"`
+	Nation string `json:"nation"`
+}
+
+// Known reports whether the providers knew the name.
+func (i AgifyInfo) Known() bool {
+	return i.Sex != ""
+}
+
+// Valid returns the info with the fields that fail validation cleared, so
+// a wrong answer of a provider counts as no answer. An info whose sex is
+// cleared is no longer Known.
+func (i AgifyInfo) Valid() AgifyInfo {
+	var age, sex, nation ValidationError
+	age.CheckAge("age", i.Age)
+	sex.CheckSex("sex", i.Sex, true)
+	nation.CheckNation("nation", i.Nation)
+
+	if age.Err() != nil {
+		i.Age = 0
+	}
+	if sex.Err() != nil {
+		i.Sex = ""
+	}
+	if nation.Err() != nil {
+		i.Nation = ""
+	}
+	return i
+}
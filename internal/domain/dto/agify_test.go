@@ -0,0 +1,37 @@
+package dto
+
+import "testing"
+
+func TestAgifyInfoKnown(t *testing.T) {
+	for info, want := range map[AgifyInfo]bool{
+		{}:                                    false,
+		{Age: 42, Nation: "RU"}:               false,
+		{Sex: SexFemale}:                      true,
+		{Age: 42, Sex: SexMale, Nation: "RU"}: true,
+	} {
+		if got := info.Known(); got != want {
+			t.Errorf("%+v.Known() = %v, want %v", info, got, want)
+		}
+	}
+}
+
+func TestAgifyInfoValid(t *testing.T) {
+	tests := []struct {
+		name string
+		info AgifyInfo
+		want AgifyInfo
+	}{
+		{"valid", AgifyInfo{Age: 42, Sex: SexMale, Nation: "RU"}, AgifyInfo{Age: 42, Sex: SexMale, Nation: "RU"}},
+		{"unknown nation code", AgifyInfo{Age: 42, Sex: SexMale, Nation: "XX"}, AgifyInfo{Age: 42, Sex: SexMale}},
+		{"age above MaxAge", AgifyInfo{Age: MaxAge + 1, Sex: SexFemale, Nation: "IE"}, AgifyInfo{Sex: SexFemale, Nation: "IE"}},
+		{"negative age", AgifyInfo{Age: -1, Sex: SexFemale}, AgifyInfo{Sex: SexFemale}},
+		{"unknown sex", AgifyInfo{Age: 42, Sex: "unknown", Nation: "RU"}, AgifyInfo{Age: 42, Nation: "RU"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Valid(); got != tt.want {
+				t.Errorf("Valid() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
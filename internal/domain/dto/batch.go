@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrBatchAborted = errors.New("batch aborted")
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchOps is the order in which the operations of a batch are applied.
+var BatchOps = []BatchOp{BatchCreate, BatchUpdate, BatchDelete}
+
+// BatchItem is one operation of a batch. A non-zero Version of an update
+// or delete must match the stored version, as in single writes.
+type BatchItem struct {
+	Op      BatchOp
+	ID      uuid.UUID
+	Version int
+	People  CreatePeople
+}
+
+// BatchResult is the outcome of the BatchItem with the same index. People
+// is set for applied creates and updates.
+type BatchResult struct {
+	ID     uuid.UUID
+	People *People
+	Err    error
+}
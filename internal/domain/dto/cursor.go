@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the last sort key of a page, with the point in time the pages
+// are read at, if any. Clients only see it as an opaque token produced by
+// Encode.
+type Cursor struct {
+	Sort   string     `json:"s,omitempty"`
+	AsOf   *time.Time `json:"t,omitempty"`
+	Values []string   `json:"v,omitempty"`
+	ID     uuid.UUID  `json:"id"`
+}
+
+func NewCursor(people People, sort []SortField, asOf *time.Time) *Cursor {
+	c := &Cursor{Sort: FormatSort(sort), AsOf: asOf, ID: people.ID}
+	for _, field := range sort {
+		c.Values = append(c.Values, people.SortValue(field.Field))
+	}
+	return c
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Matches reports whether the cursor was issued for the given sort order.
+func (c Cursor) Matches(sort []SortField) bool {
+	return c.Sort == FormatSort(sort) && len(c.Values) == len(sort)
+}
+
+// MatchesAsOf reports whether the cursor was issued for pages read at
+// asOf, nil for the current state.
+func (c Cursor) MatchesAsOf(asOf *time.Time) bool {
+	if c.AsOf == nil || asOf == nil {
+		return c.AsOf == nil && asOf == nil
+	}
+	return c.AsOf.Equal(*asOf)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
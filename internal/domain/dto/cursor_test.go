@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorAsOf(t *testing.T) {
+	asOf := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	people := People{ID: uuid.New(), LastName: "Petrov"}
+	sort := []SortField{{Field: "last_name"}}
+
+	c, err := DecodeCursor(NewCursor(people, sort, &asOf).Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor(): %v", err)
+	}
+	if !c.Matches(sort) {
+		t.Errorf("cursor does not match its sort")
+	}
+
+	later := asOf.Add(time.Hour)
+	inZone := asOf.In(time.FixedZone("UTC+3", 3*60*60))
+	tests := []struct {
+		name string
+		asOf *time.Time
+		want bool
+	}{
+		{"same time", &asOf, true},
+		{"same time in another zone", &inZone, true},
+		{"other time", &later, false},
+		{"current state", nil, false},
+	}
+	for _, tt := range tests {
+		if got := c.MatchesAsOf(tt.asOf); got != tt.want {
+			t.Errorf("%s: MatchesAsOf() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	current := NewCursor(people, sort, nil)
+	if !current.MatchesAsOf(nil) || current.MatchesAsOf(&asOf) {
+		t.Errorf("a cursor of the current state must only match no as_of")
+	}
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventCreated  = "people.created"
+	EventUpdated  = "people.updated"
+	EventDeleted  = "people.deleted"
+	EventRestored = "people.restored"
+	EventPurged   = "people.purged"
+	// EventEnriched follows EventCreated for people created from a name
+	// enriched by the external providers.
+	EventEnriched = "people.enriched"
+)
+
+// EventTypes lists every event type, e.g. for subscriptions to validate
+// against.
+var EventTypes = []string{EventCreated, EventUpdated, EventDeleted, EventRestored, EventPurged, EventEnriched}
+
+// eventTypes maps history actions onto the events they publish.
+var eventTypes = map[string]string{
+	HistoryCreate:  EventCreated,
+	HistoryUpdate:  EventUpdated,
+	HistoryDelete:  EventDeleted,
+	HistoryRestore: EventRestored,
+	HistoryPurge:   EventPurged,
+}
+
+// EventType returns the event published for a history action.
+func EventType(action string) string {
+	return eventTypes[action]
+}
+
+// Event is a change of a person published to other systems. People is the
+// state after the change, or the last state for a purge. Delivery is
+// at-least-once, so consumers should deduplicate by ID. IDs increase in
+// the order events are published, so a stream resumes after the last one
+// seen.
+type Event struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	PeopleID   uuid.UUID `json:"people_id"`
+	People     *People   `json:"people"`
+	Actor      string    `json:"actor"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
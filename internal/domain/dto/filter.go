@@ -1,7 +1,79 @@
 package dto
 
+import (
+	"strings"
+	"time"
+)
+
+// Filter selects people. The conditions are also stored as JSON with a
+// webhook; pagination and the time filters only apply to list requests.
 type Filter struct {
-	Limit   int
-	Offset  int
-	Deleted bool
+	Limit          int         `json:"-"`
+	Offset         int         `json:"-"`
+	After          *Cursor     `json:"-"`
+	Sort           []SortField `json:"-"`
+	Deleted        bool        `json:"-"`
+	IncludeDeleted bool        `json:"-"`
+	AsOf           *time.Time  `json:"-"`
+
+	FirstName        string     `json:"first_name,omitempty"`
+	FirstNamePrefix  string     `json:"first_name_prefix,omitempty"`
+	LastName         string     `json:"last_name,omitempty"`
+	LastNamePrefix   string     `json:"last_name_prefix,omitempty"`
+	Patronymic       string     `json:"patronymic,omitempty"`
+	PatronymicPrefix string     `json:"patronymic_prefix,omitempty"`
+	AgeMin           *int       `json:"age_min,omitempty"`
+	AgeMax           *int       `json:"age_max,omitempty"`
+	Sex              string     `json:"sex,omitempty"`
+	Nations          []string   `json:"nations,omitempty"`
+	UpdatedSince     *time.Time `json:"-"`
+	CreatedBefore    *time.Time `json:"-"`
+}
+
+// Matches tells whether the person passes the conditions of the filter.
+// Pagination, sort and AsOf are ignored. Names are compared
+// case-insensitively, like the repository does.
+func (f *Filter) Matches(people *People) bool {
+	switch {
+	case !f.IncludeDeleted && people.Deleted != f.Deleted:
+		return false
+	case f.FirstName != "" && !strings.EqualFold(people.FirstName, f.FirstName):
+		return false
+	case f.FirstNamePrefix != "" && !hasPrefixFold(people.FirstName, f.FirstNamePrefix):
+		return false
+	case f.LastName != "" && !strings.EqualFold(people.LastName, f.LastName):
+		return false
+	case f.LastNamePrefix != "" && !hasPrefixFold(people.LastName, f.LastNamePrefix):
+		return false
+	case f.Patronymic != "" && !strings.EqualFold(people.Patronymic, f.Patronymic):
+		return false
+	case f.PatronymicPrefix != "" && !hasPrefixFold(people.Patronymic, f.PatronymicPrefix):
+		return false
+	case f.AgeMin != nil && people.Age < *f.AgeMin:
+		return false
+	case f.AgeMax != nil && people.Age > *f.AgeMax:
+		return false
+	case f.Sex != "" && people.Sex != f.Sex:
+		return false
+	case len(f.Nations) > 0 && !contains(f.Nations, people.Nation):
+		return false
+	case f.UpdatedSince != nil && people.UpdatedAt.Before(*f.UpdatedSince):
+		return false
+	case f.CreatedBefore != nil && !people.CreatedAt.Before(*f.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
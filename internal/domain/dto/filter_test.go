@@ -0,0 +1,27 @@
+package dto
+
+import "testing"
+
+func TestFilterMatchesNames(t *testing.T) {
+	people := &People{FirstName: "Ivan", LastName: "Petrov", Patronymic: "Sergeevich"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"exact", Filter{FirstName: "Ivan"}, true},
+		{"exact other case", Filter{FirstName: "IVAN", LastName: "petrov"}, true},
+		{"exact is not a prefix", Filter{FirstName: "Iva"}, false},
+		{"other name", Filter{Patronymic: "Ivanovich"}, false},
+		{"prefix other case", Filter{LastNamePrefix: "pet"}, true},
+		{"prefix mismatch", Filter{PatronymicPrefix: "Iv"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(people); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
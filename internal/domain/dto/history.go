@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryRestore = "restore"
+	HistoryPurge   = "purge"
+)
+
+// HistoryEntry is one recorded change of a person. Before is nil for a
+// create and After is nil for a purge.
+type HistoryEntry struct {
+	ID        int64     `json:"id"`
+	PeopleID  uuid.UUID `json:"people_id"`
+	Action    string    `json:"action"`
+	Before    *People   `json:"before"`
+	After     *People   `json:"after"`
+	Actor     string    `json:"actor"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type HistoryPage struct {
+	Entries []HistoryEntry
+	Total   int
+}
+
+// UnknownActor is recorded for the changes nobody claimed.
+const UnknownActor = "unknown"
+
+// ClaimedActorPrefix labels an actor named by the client itself. Callers
+// are not authenticated, so such an actor is only what the client says
+// it is.
+const ClaimedActorPrefix = "claimed:"
+
+// ClaimedActor is the actor recorded for a client naming itself name.
+func ClaimedActor(name string) string {
+	if name == "" {
+		return UnknownActor
+	}
+	return ClaimedActorPrefix + name
+}
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes changes made with it to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return UnknownActor
+}
@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClaimedActor(t *testing.T) {
+	if got := ClaimedActor("alice"); got != "claimed:alice" {
+		t.Errorf(`ClaimedActor("alice") = %q, want "claimed:alice"`, got)
+	}
+	if got := ClaimedActor(""); got != UnknownActor {
+		t.Errorf(`ClaimedActor("") = %q, want %q`, got, UnknownActor)
+	}
+	if got := ActorFromContext(context.Background()); got != UnknownActor {
+		t.Errorf("ActorFromContext() = %q, want %q", got, UnknownActor)
+	}
+}
@@ -1,17 +1,28 @@
 package kafka
 
+import (
+	"errors"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+)
+
 type Error struct {
-	Message string `json:"message"`
-	Error   string `json:"error"`
+	Message string           `json:"message"`
+	Error   string           `json:"error"`
+	Fields  []dto.FieldError `json:"fields,omitempty"`
 }
 
-var (
-	ErrRequiredFieldNotExists = Error{
-		Message: "validation failed",
-		Error:   "required field not exists",
+// NewError describes err, listing the invalid fields when it is a
+// *dto.ValidationError.
+func NewError(message string, err error) Error {
+	e := Error{
+		Message: message,
+		Error:   err.Error(),
 	}
-	ErrRequiredFieldIsEmpty = Error{
-		Message: "validation failed",
-		Error:   "required field is empty",
+	var invalid *dto.ValidationError
+	if errors.As(err, &invalid) {
+		e.Message = "validation failed"
+		e.Fields = invalid.Fields
 	}
-)
+	return e
+}
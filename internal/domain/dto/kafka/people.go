@@ -1,7 +1,26 @@
 package kafka
 
+import "github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+
 type PeopleName struct {
 	FirstName  *string `json:"name"`
 	LastName   *string `json:"surname"`
 	Patronymic string  `json:"patronymic,omitempty"`
 }
+
+// Validate returns a *dto.ValidationError if the name is not valid.
+func (p PeopleName) Validate() error {
+	var e dto.ValidationError
+	if p.FirstName == nil {
+		e.Add("name", "is required")
+	} else {
+		e.CheckName("name", *p.FirstName, true)
+	}
+	if p.LastName == nil {
+		e.Add("surname", "is required")
+	} else {
+		e.CheckName("surname", *p.LastName, true)
+	}
+	e.CheckName("patronymic", p.Patronymic, false)
+	return e.Err()
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "encoding/json"
+
+// Field is a member of an RFC 7396 JSON Merge Patch document. It tells an
+// absent member apart from an explicit null and from a value.
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+type PatchPeople struct {
+	FirstName  Field[string]
+	LastName   Field[string]
+	Patronymic Field[string]
+	Age        Field[int]
+	Sex        Field[string]
+	Nation     Field[string]
+}
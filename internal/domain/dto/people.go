@@ -2,19 +2,27 @@ package dto
 
 import (
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrVersionConflict = errors.New("version conflict")
+
 type People struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	FirstName  string    `json:"first_name" db:"first_name"`
-	LastName   string    `json:"last_name" db:"last_name"`
-	Patronymic string    `json:"patronymic" db:"patronymic"`
-	Age        int       `json:"age" db:"age"`
-	Sex        string    `json:"sex" db:"sex"`
-	Nation     string    `json:"nation" db:"nation"`
-	Deleted    bool      `json:"deleted" db:"deleted"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	FirstName  string     `json:"first_name" db:"first_name"`
+	LastName   string     `json:"last_name" db:"last_name"`
+	Patronymic string     `json:"patronymic" db:"patronymic"`
+	Age        int        `json:"age" db:"age"`
+	Sex        string     `json:"sex" db:"sex"`
+	Nation     string     `json:"nation" db:"nation"`
+	Deleted    bool       `json:"deleted" db:"deleted"`
+	Version    int        `json:"version" db:"version"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type CreatePeople struct {
@@ -28,6 +36,12 @@ type CreatePeople struct {
 
 type Peoples []People
 
+type PeoplesPage struct {
+	Peoples    Peoples
+	Total      int
+	NextCursor *Cursor
+}
+
 func (r *People) MarshallBinary() ([]byte, error) {
 	return json.Marshal(r)
 }
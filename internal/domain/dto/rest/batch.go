@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+const MaxBatchSize = 1000
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchOperation is one operation of a batch request. Version is optional
+// and, as If-Match does for single writes, guards an update or delete
+// against changes made since that version was read.
+type BatchOperation struct {
+	Op      dto.BatchOp          `json:"op"`
+	ID      *uuid.UUID           `json:"id,omitempty"`
+	Version *int                 `json:"version,omitempty"`
+	Data    *CreatePeopleRequest `json:"data,omitempty"`
+}
+
+// Validate checks a single operation. An invalid operation fails on its
+// own and does not reject the whole request. Invalid data is reported as
+// a *dto.ValidationError.
+func (o *BatchOperation) Validate() error {
+	switch o.Op {
+	case dto.BatchCreate:
+		if o.ID != nil {
+			return fmt.Errorf("create must not have an id")
+		}
+		if o.Version != nil {
+			return fmt.Errorf("create must not have a version")
+		}
+	case dto.BatchUpdate, dto.BatchDelete:
+		if o.ID == nil {
+			return fmt.Errorf("%s requires an id", o.Op)
+		}
+		if o.Version != nil && *o.Version < 1 {
+			return fmt.Errorf("version must be positive")
+		}
+	default:
+		return fmt.Errorf("op must be create | update | delete")
+	}
+
+	if o.Op == dto.BatchDelete {
+		return nil
+	}
+	if o.Data == nil {
+		return fmt.Errorf("%s requires data", o.Op)
+	}
+	return dto.CreatePeople(*o.Data).Validate()
+}
+
+type BatchRequest struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+func (b *BatchRequest) Bind(r *http.Request) error {
+	if b.Mode == "" {
+		b.Mode = BatchModeBestEffort
+	}
+	if b.Mode != BatchModeAtomic && b.Mode != BatchModeBestEffort {
+		return fmt.Errorf("mode must be atomic | best_effort")
+	}
+
+	if len(b.Operations) == 0 || len(b.Operations) > MaxBatchSize {
+		return fmt.Errorf("operations must contain between 1 and %d items", MaxBatchSize)
+	}
+
+	return nil
+}
+
+type BatchItemResponse struct {
+	Index  int              `json:"index"`
+	Op     dto.BatchOp      `json:"op"`
+	ID     *uuid.UUID       `json:"id,omitempty"`
+	Status int              `json:"status"`
+	Error  string           `json:"error,omitempty"`
+	Fields []dto.FieldError `json:"fields,omitempty"`
+	Data   *PeopleResponse  `json:"data,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchItemResponse `json:"results"`
+}
+
+func (*BatchResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
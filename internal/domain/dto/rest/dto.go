@@ -1,32 +1,286 @@
 package rest
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
 )
 
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+)
+
 type FilterRequest struct {
-	Limit   int  `json:"limit"`
-	Offset  int  `json:"offset"`
-	Deleted bool `json:"deleted"`
+	Limit          int
+	Offset         int
+	After          *dto.Cursor
+	Sort           []dto.SortField
+	Deleted        bool
+	IncludeDeleted bool
+	AsOf           *time.Time
+
+	FirstName        string
+	FirstNamePrefix  string
+	LastName         string
+	LastNamePrefix   string
+	Patronymic       string
+	PatronymicPrefix string
+	AgeMin           *int
+	AgeMax           *int
+	Sex              string
+	Nations          []string
+	UpdatedSince     *time.Time
+	CreatedBefore    *time.Time
 }
 
+// Bind fills the filter from the URL query of a list request.
 func (f *FilterRequest) Bind(r *http.Request) error {
+	query := r.URL.Query()
+
+	limit, err := intParam(query, "limit")
+	if err != nil {
+		return err
+	}
+	f.Limit = DefaultLimit
+	if limit != nil {
+		f.Limit = *limit
+	}
+	if f.Limit < 1 || f.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+
+	offset, err := intParam(query, "offset")
+	if err != nil {
+		return err
+	}
+	if offset != nil {
+		if *offset < 0 {
+			return fmt.Errorf("offset must not be negative")
+		}
+		f.Offset = *offset
+	}
+
+	if f.Sort, err = dto.ParseSort(query.Get("sort")); err != nil {
+		return err
+	}
+
+	if v := query.Get("after"); v != "" {
+		if f.Offset != 0 {
+			return fmt.Errorf("after and offset are mutually exclusive")
+		}
+		if f.After, err = dto.DecodeCursor(v); err != nil {
+			return fmt.Errorf("after: %w", err)
+		}
+		if !f.After.Matches(f.Sort) {
+			return fmt.Errorf("after: cursor was issued for a different sort")
+		}
+	}
+
+	if v := query.Get("deleted"); v != "" {
+		if f.Deleted, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("deleted: %w", err)
+		}
+	}
+
+	if v := query.Get("include_deleted"); v != "" {
+		if f.IncludeDeleted, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("include_deleted: %w", err)
+		}
+	}
+
+	if f.AsOf, err = timeParam(query, "as_of"); err != nil {
+		return err
+	}
+	if f.After != nil && !f.After.MatchesAsOf(f.AsOf) {
+		return fmt.Errorf("after: cursor was issued for a different as_of")
+	}
+
+	return f.bindConditions(query)
+}
+
+// bindConditions fills the conditions a person is matched against, which
+// list requests and event streams share.
+func (f *FilterRequest) bindConditions(query url.Values) error {
+	var err error
+
+	f.FirstName = query.Get("first_name")
+	f.FirstNamePrefix = query.Get("first_name_prefix")
+	f.LastName = query.Get("last_name")
+	f.LastNamePrefix = query.Get("last_name_prefix")
+	f.Patronymic = query.Get("patronymic")
+	f.PatronymicPrefix = query.Get("patronymic_prefix")
+
+	if f.AgeMin, err = intParam(query, "age_min"); err != nil {
+		return err
+	}
+	if f.AgeMax, err = intParam(query, "age_max"); err != nil {
+		return err
+	}
+	if f.AgeMin != nil && f.AgeMax != nil && *f.AgeMin > *f.AgeMax {
+		return fmt.Errorf("age_min must not be greater than age_max")
+	}
+
+	f.Sex = query.Get("sex")
+	if f.Sex != "" && f.Sex != "male" && f.Sex != "female" {
+		return fmt.Errorf("sex must be male | female")
+	}
+
+	for _, v := range query["nation"] {
+		for _, nation := range strings.Split(v, ",") {
+			if nation = strings.TrimSpace(nation); nation != "" {
+				if !dto.IsNation(nation) {
+					return fmt.Errorf("nation must be ISO 3166-1 alpha-2 country codes")
+				}
+				f.Nations = append(f.Nations, nation)
+			}
+		}
+	}
+
+	if f.UpdatedSince, err = timeParam(query, "updated_since"); err != nil {
+		return err
+	}
+	if f.CreatedBefore, err = timeParam(query, "created_before"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EventFilterRequest is the filter of an event stream. It takes the
+// conditions of a list request only.
+type EventFilterRequest FilterRequest
+
+// listParams have no meaning on an event stream, which is neither paged
+// nor read at a point in time and always includes deleted people.
+var listParams = []string{"limit", "offset", "after", "sort", "deleted", "include_deleted", "as_of"}
+
+// Bind fills the filter from the URL query of an event stream and rejects
+// the parameters of list requests.
+func (f *EventFilterRequest) Bind(r *http.Request) error {
+	query := r.URL.Query()
+	for _, name := range listParams {
+		if query.Has(name) {
+			return fmt.Errorf("%s is not supported on an event stream", name)
+		}
+	}
+	return (*FilterRequest)(f).bindConditions(query)
+}
+
+func timeParam(query url.Values, name string) (*time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	// An unescaped "+" of the offset arrives as a space.
+	t, err := time.Parse(time.RFC3339, strings.ReplaceAll(v, " ", "+"))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return &t, nil
+}
+
+// AsOf reads the optional as_of point in time of a single-person read.
+func AsOf(r *http.Request) (*time.Time, error) {
+	return timeParam(r.URL.Query(), "as_of")
+}
+
+func intParam(query url.Values, name string) (*int, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &n, nil
+}
+
+type PageRequest struct {
+	Limit  int
+	Offset int
+}
+
+// Bind fills limit and offset from the URL query.
+func (p *PageRequest) Bind(r *http.Request) error {
+	query := r.URL.Query()
+
+	limit, err := intParam(query, "limit")
+	if err != nil {
+		return err
+	}
+	p.Limit = DefaultLimit
+	if limit != nil {
+		p.Limit = *limit
+	}
+	if p.Limit < 1 || p.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+
+	offset, err := intParam(query, "offset")
+	if err != nil {
+		return err
+	}
+	if offset != nil {
+		if *offset < 0 {
+			return fmt.Errorf("offset must not be negative")
+		}
+		p.Offset = *offset
+	}
+
+	return nil
+}
+
+type SearchRequest struct {
+	Query string
+	Limit int
+}
+
+// Bind fills the search request from the URL query.
+func (s *SearchRequest) Bind(r *http.Request) error {
+	query := r.URL.Query()
+
+	s.Query = strings.TrimSpace(query.Get("q"))
+	if s.Query == "" {
+		return fmt.Errorf("q is required")
+	}
+
+	limit, err := intParam(query, "limit")
+	if err != nil {
+		return err
+	}
+	s.Limit = DefaultLimit
+	if limit != nil {
+		s.Limit = *limit
+	}
+	if s.Limit < 1 || s.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+
 	return nil
 }
 
 type PeopleResponse struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	FirstName  string    `json:"first_name" db:"first_name"`
-	LastName   string    `json:"last_name" db:"last_name"`
-	Patronymic string    `json:"patronymic" db:"patronymic"`
-	Age        int       `json:"age" db:"age"`
-	Sex        string    `json:"sex" db:"sex"`
-	Nation     string    `json:"nation" db:"nation"`
-	Deleted    bool      `json:"deleted" db:"deleted"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	FirstName  string     `json:"first_name" db:"first_name"`
+	LastName   string     `json:"last_name" db:"last_name"`
+	Patronymic string     `json:"patronymic" db:"patronymic"`
+	Age        int        `json:"age" db:"age"`
+	Sex        string     `json:"sex" db:"sex"`
+	Nation     string     `json:"nation" db:"nation"`
+	Deleted    bool       `json:"deleted" db:"deleted"`
+	Version    int        `json:"version" db:"version"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 type CreatePeopleRequest struct {
@@ -42,15 +296,18 @@ func (c *CreatePeopleRequest) Bind(r *http.Request) error {
 	return nil
 }
 
+// UpdatePeopleRequest is a JSON Merge Patch of a person: absent members are
+// left untouched and null clears a nullable column.
 type UpdatePeopleRequest struct {
-	FirstName  string `json:"first_name"`
-	LastName   string `json:"last_name"`
-	Patronymic string `json:"patronymic"`
-	Age        int    `json:"age"`
-	Sex        string `json:"sex"`
-	Nation     string `json:"nation"`
+	FirstName  dto.Field[string] `json:"first_name"`
+	LastName   dto.Field[string] `json:"last_name"`
+	Patronymic dto.Field[string] `json:"patronymic"`
+	Age        dto.Field[int]    `json:"age"`
+	Sex        dto.Field[string] `json:"sex"`
+	Nation     dto.Field[string] `json:"nation"`
 }
 
+// Bind accepts any patch; the values are validated with the person.
 func (u *UpdatePeopleRequest) Bind(r *http.Request) error {
 	return nil
 }
@@ -63,7 +320,12 @@ func NewPeopleResponse(people dto.People) *PeopleResponse {
 		Patronymic: people.Patronymic,
 		Age:        people.Age,
 		Sex:        people.Sex,
+		Nation:     people.Nation,
 		Deleted:    people.Deleted,
+		Version:    people.Version,
+		CreatedAt:  people.CreatedAt,
+		UpdatedAt:  people.UpdatedAt,
+		DeletedAt:  people.DeletedAt,
 	}
 }
 
@@ -71,10 +333,72 @@ func (*PeopleResponse) Render(w http.ResponseWriter, req *http.Request) error {
 	return nil
 }
 
-func NewListPeopleResponse(peoples *dto.Peoples) []render.Renderer {
-	r := make([]render.Renderer, len(*peoples))
-	for idx, people := range *peoples {
-		r[idx] = NewPeopleResponse(people)
+type ListPeopleResponse struct {
+	Items      []*PeopleResponse `json:"items"`
+	Total      int               `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     *int              `json:"offset,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func NewListPeopleResponse(page *dto.PeoplesPage, filter FilterRequest) *ListPeopleResponse {
+	resp := &ListPeopleResponse{
+		Items: make([]*PeopleResponse, len(page.Peoples)),
+		Total: page.Total,
+		Limit: filter.Limit,
+	}
+	for idx, people := range page.Peoples {
+		resp.Items[idx] = NewPeopleResponse(people)
+	}
+	if filter.After == nil {
+		resp.Offset = &filter.Offset
+	}
+	if page.NextCursor != nil {
+		resp.NextCursor = page.NextCursor.Encode()
+	}
+	return resp
+}
+
+func (*ListPeopleResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
+
+type SearchHitResponse struct {
+	*PeopleResponse
+	Score float64 `json:"score"`
+}
+
+func (*SearchHitResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
+
+func NewListSearchHitResponse(hits *dto.SearchHits) []render.Renderer {
+	r := make([]render.Renderer, len(*hits))
+	for idx, hit := range *hits {
+		r[idx] = &SearchHitResponse{
+			PeopleResponse: NewPeopleResponse(hit.People),
+			Score:          hit.Score,
+		}
 	}
 	return r
 }
+
+type ListHistoryResponse struct {
+	Items  []dto.HistoryEntry `json:"items"`
+	Total  int                `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+func NewListHistoryResponse(page *dto.HistoryPage, req PageRequest) *ListHistoryResponse {
+	return &ListHistoryResponse{
+		Items:  page.Entries,
+		Total:  page.Total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+}
+
+func (*ListHistoryResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
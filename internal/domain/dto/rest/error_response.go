@@ -1,8 +1,11 @@
 package rest
 
 import (
-	"github.com/go-chi/render"
+	"errors"
 	"net/http"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/go-chi/render"
 )
 
 type ErrResponse struct {
@@ -12,6 +15,8 @@ type ErrResponse struct {
 	StatusText string `json:"status"`
 	AppCode    int64  `json:"code,omitempty"`
 	ErrorText  string `json:"error,omitempty"`
+
+	Fields []dto.FieldError `json:"fields,omitempty"`
 }
 
 func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
@@ -32,13 +37,39 @@ var (
 		HTTPStatusCode: http.StatusBadRequest,
 		StatusText:     "Bad request",
 	}
+	ErrForbidden = &ErrResponse{
+		HTTPStatusCode: http.StatusForbidden,
+		StatusText:     "Forbidden",
+	}
+	ErrPreconditionFailed = &ErrResponse{
+		HTTPStatusCode: http.StatusPreconditionFailed,
+		StatusText:     "Precondition failed",
+	}
 )
 
+// ErrUnprocessableEntity lists the invalid fields when err is a
+// *dto.ValidationError.
 func ErrUnprocessableEntity(err error) *ErrResponse {
-	return &ErrResponse{
+	resp := &ErrResponse{
 		HTTPStatusCode: http.StatusUnprocessableEntity,
 		StatusText:     "Unprocessable entity",
 		Err:            err,
 		ErrorText:      err.Error(),
 	}
+	var invalid *dto.ValidationError
+	if errors.As(err, &invalid) {
+		resp.ErrorText = "validation failed"
+		resp.Fields = invalid.Fields
+	}
+	return resp
+}
+
+// ErrInvalidRequest is ErrBadRequest telling the client what is wrong.
+func ErrInvalidRequest(err error) *ErrResponse {
+	return &ErrResponse{
+		HTTPStatusCode: http.StatusBadRequest,
+		StatusText:     "Bad request",
+		Err:            err,
+		ErrorText:      err.Error(),
+	}
 }
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+type EventResponse struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	PeopleID   uuid.UUID       `json:"people_id"`
+	People     *PeopleResponse `json:"people"`
+	Actor      string          `json:"actor"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+func NewEventResponse(event dto.Event) *EventResponse {
+	return &EventResponse{
+		ID:         event.ID,
+		Type:       event.Type,
+		PeopleID:   event.PeopleID,
+		People:     NewPeopleResponse(*event.People),
+		Actor:      event.Actor,
+		OccurredAt: event.OccurredAt,
+	}
+}
+
+// LastEventID reads the ID of the last event a reconnecting stream client
+// has seen, from the Last-Event-ID header or, as EventSource cannot set
+// headers on the first connection, the last_event_id query parameter.
+func LastEventID(r *http.Request) (*int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("last event id must be a non-negative integer")
+	}
+	return &id, nil
+}
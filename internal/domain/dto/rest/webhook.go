@@ -0,0 +1,191 @@
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Dmitrij-Kochetov/peoples/internal/domain/dto"
+	"github.com/google/uuid"
+)
+
+type WebhookRequest struct {
+	URL        string     `json:"url"`
+	EventTypes []string   `json:"event_types"`
+	Filter     dto.Filter `json:"filter"`
+	Secret     string     `json:"secret"`
+	Enabled    *bool      `json:"enabled"`
+}
+
+func (h *WebhookRequest) Bind(r *http.Request) error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	// The sender checks the address again when it connects, as the name
+	// may resolve differently by then.
+	addrs, err := net.DefaultResolver.LookupNetIP(r.Context(), "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("url host cannot be resolved")
+	}
+	for _, addr := range addrs {
+		if !dto.IsPublicAddr(addr) {
+			return fmt.Errorf("url must not point to a private address")
+		}
+	}
+
+	for _, typ := range h.EventTypes {
+		if !isEventType(typ) {
+			return fmt.Errorf("unknown event type %q", typ)
+		}
+	}
+
+	if h.Filter.Sex != "" && h.Filter.Sex != "male" && h.Filter.Sex != "female" {
+		return fmt.Errorf("filter: sex must be male | female")
+	}
+	if h.Filter.AgeMin != nil && h.Filter.AgeMax != nil && *h.Filter.AgeMin > *h.Filter.AgeMax {
+		return fmt.Errorf("filter: age_min must not be greater than age_max")
+	}
+
+	return nil
+}
+
+func isEventType(typ string) bool {
+	for _, t := range dto.EventTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook returns the webhook described by the request. It is enabled
+// unless the request says otherwise.
+func (h *WebhookRequest) Webhook() dto.Webhook {
+	hook := dto.Webhook{
+		URL:        h.URL,
+		EventTypes: h.EventTypes,
+		Filter:     h.Filter,
+		Secret:     h.Secret,
+		Enabled:    true,
+	}
+	if h.Enabled != nil {
+		hook.Enabled = *h.Enabled
+	}
+	return hook
+}
+
+type WebhookResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	URL        string     `json:"url"`
+	EventTypes []string   `json:"event_types"`
+	Filter     dto.Filter `json:"filter"`
+	// Secret is only returned when the webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	Failures  int       `json:"failures"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewWebhookResponse(hook dto.Webhook) *WebhookResponse {
+	eventTypes := hook.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return &WebhookResponse{
+		ID:         hook.ID,
+		URL:        hook.URL,
+		EventTypes: eventTypes,
+		Filter:     hook.Filter,
+		Enabled:    hook.Enabled,
+		Failures:   hook.Failures,
+		CreatedAt:  hook.CreatedAt,
+		UpdatedAt:  hook.UpdatedAt,
+	}
+}
+
+func (*WebhookResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
+
+type ListWebhookResponse struct {
+	Items  []*WebhookResponse `json:"items"`
+	Total  int                `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+func NewListWebhookResponse(page *dto.WebhooksPage, req PageRequest) *ListWebhookResponse {
+	resp := &ListWebhookResponse{
+		Items:  make([]*WebhookResponse, len(page.Webhooks)),
+		Total:  page.Total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	for idx, hook := range page.Webhooks {
+		resp.Items[idx] = NewWebhookResponse(hook)
+	}
+	return resp
+}
+
+func (*ListWebhookResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+func NewWebhookDeliveryResponse(d dto.WebhookDelivery) *WebhookDeliveryResponse {
+	resp := &WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.Event.ID,
+		EventType:      d.Event.Type,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == dto.DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+type ListWebhookDeliveryResponse struct {
+	Items  []*WebhookDeliveryResponse `json:"items"`
+	Total  int                        `json:"total"`
+	Limit  int                        `json:"limit"`
+	Offset int                        `json:"offset"`
+}
+
+func NewListWebhookDeliveryResponse(page *dto.WebhookDeliveriesPage, req PageRequest) *ListWebhookDeliveryResponse {
+	resp := &ListWebhookDeliveryResponse{
+		Items:  make([]*WebhookDeliveryResponse, len(page.Deliveries)),
+		Total:  page.Total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	for idx, d := range page.Deliveries {
+		resp.Items[idx] = NewWebhookDeliveryResponse(d)
+	}
+	return resp
+}
+
+func (*ListWebhookDeliveryResponse) Render(w http.ResponseWriter, req *http.Request) error {
+	return nil
+}
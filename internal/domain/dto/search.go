@@ -0,0 +1,8 @@
+package dto
+
+type SearchHit struct {
+	People
+	Score float64 `json:"score" db:"score"`
+}
+
+type SearchHits []SearchHit
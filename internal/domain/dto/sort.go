@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortableFields lists the fields a client may order people by. Ties are
+// always broken by id, so the order is total and stable across pages.
+var SortableFields = []string{"first_name", "last_name", "patronymic", "age", "sex", "nation", "created_at", "updated_at"}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a "last_name,-age" style sort specification.
+func ParseSort(spec string) ([]SortField, error) {
+	var sort []SortField
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: part[1:], Desc: true}
+		}
+
+		if !isSortable(field.Field) {
+			return nil, fmt.Errorf("cannot sort by %q", field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field.Field)
+		}
+		seen[field.Field] = true
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+func FormatSort(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, field := range sort {
+		parts[i] = field.Field
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func isSortable(field string) bool {
+	for _, f := range SortableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SortValue returns the value of a sortable field in the form it is kept
+// in a cursor.
+func (r *People) SortValue(field string) string {
+	switch field {
+	case "first_name":
+		return r.FirstName
+	case "last_name":
+		return r.LastName
+	case "patronymic":
+		return r.Patronymic
+	case "age":
+		return strconv.Itoa(r.Age)
+	case "sex":
+		return r.Sex
+	case "nation":
+		return r.Nation
+	case "created_at":
+		return r.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return r.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return ""
+}
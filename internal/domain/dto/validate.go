@@ -0,0 +1,146 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxNameLength = 64
+	MaxAge        = 150
+
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// FieldError tells what is wrong with one field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns the error, or nil when no field is invalid.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// CheckName checks a name consists of letters, with single spaces,
+// hyphens or apostrophes between them. An empty name is only allowed
+// when it is not required.
+func (e *ValidationError) CheckName(field, name string, required bool) {
+	switch {
+	case name == "":
+		if required {
+			e.Add(field, "is required")
+		}
+	case utf8.RuneCountInString(name) > MaxNameLength:
+		e.Add(field, fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	case !isName(name):
+		e.Add(field, "must consist of letters, with spaces, hyphens or apostrophes between them")
+	}
+}
+
+func isName(name string) bool {
+	prevLetter := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			prevLetter = true
+		case unicode.Is(unicode.Mn, r) && prevLetter:
+		case (r == ' ' || r == '-' || r == '\'' || r == '’') && prevLetter:
+			prevLetter = false
+		default:
+			return false
+		}
+	}
+	return prevLetter
+}
+
+// CheckAge checks an age is within 0 and MaxAge, 0 being unknown.
+func (e *ValidationError) CheckAge(field string, age int) {
+	if age < 0 || age > MaxAge {
+		e.Add(field, fmt.Sprintf("must be between 0 and %d", MaxAge))
+	}
+}
+
+func (e *ValidationError) CheckSex(field, sex string, required bool) {
+	if sex == "" && !required {
+		return
+	}
+	if sex != SexMale && sex != SexFemale {
+		e.Add(field, "must be male | female")
+	}
+}
+
+// CheckNation checks a nation is an ISO 3166-1 alpha-2 code. Empty is
+// unknown.
+func (e *ValidationError) CheckNation(field, nation string) {
+	if nation != "" && !IsNation(nation) {
+		e.Add(field, "must be an ISO 3166-1 alpha-2 country code")
+	}
+}
+
+// IsNation reports whether nation is an ISO 3166-1 alpha-2 code.
+func IsNation(nation string) bool {
+	return nations[nation]
+}
+
+// Validate returns a *ValidationError if the person is not valid.
+func (c CreatePeople) Validate() error {
+	var e ValidationError
+	e.CheckName("first_name", c.FirstName, true)
+	e.CheckName("last_name", c.LastName, true)
+	e.CheckName("patronymic", c.Patronymic, false)
+	e.CheckAge("age", c.Age)
+	e.CheckSex("sex", c.Sex, true)
+	e.CheckNation("nation", c.Nation)
+	return e.Err()
+}
+
+// Validate returns a *ValidationError if the patch would leave the person
+// invalid. Only the members it sets are checked; clearing a required
+// field, sex among them, is invalid.
+func (p PatchPeople) Validate() error {
+	var e ValidationError
+	if p.FirstName.Set {
+		e.CheckName("first_name", p.FirstName.Value, true)
+	}
+	if p.LastName.Set {
+		e.CheckName("last_name", p.LastName.Value, true)
+	}
+	if p.Patronymic.Set {
+		e.CheckName("patronymic", p.Patronymic.Value, false)
+	}
+	if p.Age.Set {
+		e.CheckAge("age", p.Age.Value)
+	}
+	if p.Sex.Set {
+		e.CheckSex("sex", p.Sex.Value, true)
+	}
+	if p.Nation.Set {
+		e.CheckNation("nation", p.Nation.Value)
+	}
+	return e.Err()
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreatePeopleValidate(t *testing.T) {
+	valid := CreatePeople{
+		FirstName:  "Anna-Maria",
+		LastName:   "O'Neil",
+		Patronymic: "Ивановна",
+		Age:        30,
+		Sex:        SexFemale,
+		Nation:     "IE",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *CreatePeople)
+		fields []string
+	}{
+		{"valid", func(p *CreatePeople) {}, nil},
+		{"unknown age and nation", func(p *CreatePeople) { p.Age, p.Nation, p.Patronymic = 0, "", "" }, nil},
+		{"missing names", func(p *CreatePeople) { p.FirstName, p.LastName = "", "" }, []string{"first_name", "last_name"}},
+		{"digits in name", func(p *CreatePeople) { p.FirstName = "Ann4" }, []string{"first_name"}},
+		{"trailing hyphen", func(p *CreatePeople) { p.LastName = "Smith-" }, []string{"last_name"}},
+		{"double space", func(p *CreatePeople) { p.LastName = "van  Dijk" }, []string{"last_name"}},
+		{"too long", func(p *CreatePeople) { p.Patronymic = strings.Repeat("a", MaxNameLength+1) }, []string{"patronymic"}},
+		{"negative age", func(p *CreatePeople) { p.Age = -1 }, []string{"age"}},
+		{"too old", func(p *CreatePeople) { p.Age = MaxAge + 1 }, []string{"age"}},
+		{"unknown sex", func(p *CreatePeople) { p.Sex = "robot" }, []string{"sex"}},
+		{"missing sex", func(p *CreatePeople) { p.Sex = "" }, []string{"sex"}},
+		{"lower case nation", func(p *CreatePeople) { p.Nation = "ie" }, []string{"nation"}},
+		{"unassigned nation", func(p *CreatePeople) { p.Nation = "XX" }, []string{"nation"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+
+			err := p.Validate()
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %v, want a *ValidationError", err)
+			}
+			var fields []string
+			for _, f := range invalid.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("got invalid fields %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestPatchPeopleValidate(t *testing.T) {
+	patch := PatchPeople{
+		Patronymic: Field[string]{Set: true, Null: true},
+		Age:        Field[int]{Set: true, Null: true},
+		Nation:     Field[string]{Set: true, Null: true},
+	}
+	if err := patch.Validate(); err != nil {
+		t.Fatalf("clearing nullable fields: %v", err)
+	}
+
+	// Sex is required on create, so a patch must not clear it either.
+	patch = PatchPeople{
+		FirstName: Field[string]{Set: true, Null: true},
+		Sex:       Field[string]{Set: true, Null: true},
+		Nation:    Field[string]{Set: true, Value: "Russia"},
+	}
+	var invalid *ValidationError
+	if !errors.As(patch.Validate(), &invalid) || len(invalid.Fields) != 3 {
+		t.Fatalf("got %v, want first_name, sex and nation to be invalid", patch.Validate())
+	}
+}
@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a subscription of a partner endpoint to people events. An
+// empty EventTypes subscribes to every type.
+type Webhook struct {
+	ID         uuid.UUID
+	URL        string
+	EventTypes []string
+	Filter     Filter
+	Secret     string
+	Enabled    bool
+	Failures   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type WebhooksPage struct {
+	Webhooks []Webhook
+	Total    int
+}
+
+// Accepts tells whether the event is to be delivered to the webhook.
+// Deleted people match, so that delete events are delivered.
+func (w *Webhook) Accepts(event Event) bool {
+	if !w.Enabled {
+		return false
+	}
+	if len(w.EventTypes) > 0 && !contains(w.EventTypes, event.Type) {
+		return false
+	}
+	filter := w.Filter
+	filter.IncludeDeleted = true
+	return filter.Matches(event.People)
+}
+
+// nonPublic lists the ranges outside of the IsPrivate and link-local ones
+// that webhooks must not reach: "this network" and the shared address
+// space some clouds serve their metadata on.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// IsPublicAddr tells whether a webhook may be delivered to addr. Loopback,
+// private, link-local (including the 169.254.169.254 metadata endpoint),
+// unspecified and multicast addresses are refused, so a webhook cannot
+// reach the internal network of the service.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event to be delivered to one webhook, together
+// with the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      uuid.UUID
+	Event          Event
+	Status         string
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+
+	// URL and Secret of the webhook, filled when a delivery is claimed.
+	URL    string
+	Secret string
+}
+
+type WebhookDeliveriesPage struct {
+	Deliveries []WebhookDelivery
+	Total      int
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS peoples_patronymic_trgm_idx;
+
+DROP INDEX IF EXISTS peoples_last_name_trgm_idx;
+
+DROP INDEX IF EXISTS peoples_first_name_trgm_idx;
+
+DROP EXTENSION IF EXISTS pg_trgm;
\ No newline at end of file
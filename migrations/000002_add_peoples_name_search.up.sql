@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS peoples_first_name_trgm_idx ON peoples USING GIN (first_name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS peoples_last_name_trgm_idx ON peoples USING GIN (last_name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS peoples_patronymic_trgm_idx ON peoples USING GIN (patronymic gin_trgm_ops);
\ No newline at end of file
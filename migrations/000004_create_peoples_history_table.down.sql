@@ -0,0 +1 @@
+DROP TABLE IF EXISTS peoples_history;
\ No newline at end of file
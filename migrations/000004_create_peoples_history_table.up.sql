@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS peoples_history
+(
+    id         BIGSERIAL,
+    people_id  uuid        NOT NULL,
+    action     VARCHAR     NOT NULL,
+    before     JSONB,
+    after      JSONB,
+    actor      VARCHAR     NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS peoples_history_people_id_idx ON peoples_history (people_id, id);
\ No newline at end of file
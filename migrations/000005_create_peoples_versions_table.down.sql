@@ -0,0 +1 @@
+DROP TABLE IF EXISTS peoples_versions;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS peoples_versions
+(
+    people_id  uuid        NOT NULL,
+    first_name VARCHAR     NOT NULL,
+    last_name  VARCHAR     NOT NULL,
+    patronymic VARCHAR,
+    age        INT,
+    sex        sex_enum,
+    nation     VARCHAR,
+    deleted    BOOLEAN     NOT NULL,
+    version    INT         NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to   TIMESTAMPTZ,
+    PRIMARY KEY (people_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS peoples_versions_validity_idx ON peoples_versions (valid_from, valid_to);
+
+INSERT INTO peoples_versions (people_id, first_name, last_name, patronymic, age, sex, nation, deleted, version, valid_from)
+SELECT id, first_name, last_name, patronymic, age, sex, nation, deleted, version, now()
+FROM peoples
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE peoples_versions
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS peoples_updated_at_idx;
+DROP INDEX IF EXISTS peoples_created_at_idx;
+
+ALTER TABLE peoples
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
@@ -0,0 +1,20 @@
+ALTER TABLE peoples
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+UPDATE peoples SET deleted_at = updated_at WHERE deleted;
+
+CREATE INDEX IF NOT EXISTS peoples_created_at_idx ON peoples (created_at, id);
+CREATE INDEX IF NOT EXISTS peoples_updated_at_idx ON peoples (updated_at, id);
+
+ALTER TABLE peoples_versions
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+UPDATE peoples_versions SET deleted_at = valid_from WHERE deleted;
+
+ALTER TABLE peoples_versions
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN updated_at DROP DEFAULT;
\ No newline at end of file
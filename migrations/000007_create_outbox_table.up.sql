@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id           BIGSERIAL,
+    people_id    uuid        NOT NULL,
+    event_type   VARCHAR     NOT NULL,
+    payload      JSONB       NOT NULL,
+    actor        VARCHAR     NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          uuid        DEFAULT uuid_generate_v4(),
+    url         VARCHAR     NOT NULL,
+    event_types VARCHAR[]   NOT NULL DEFAULT '{}',
+    filter      JSONB       NOT NULL DEFAULT '{}',
+    secret      VARCHAR     NOT NULL,
+    enabled     BOOLEAN     NOT NULL DEFAULT TRUE,
+    failures    INT         NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               BIGSERIAL,
+    webhook_id       uuid        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         BIGINT      NOT NULL,
+    event            JSONB       NOT NULL,
+    status           VARCHAR     NOT NULL DEFAULT 'pending',
+    attempts         INT         NOT NULL DEFAULT 0,
+    last_status_code INT,
+    last_error       VARCHAR,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at     TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE SEQUENCE IF NOT EXISTS outbox_publish_seq;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS published_seq BIGINT;
+
+-- Events published so far keep their IDs; new ones follow all of them.
+UPDATE outbox SET published_seq = id WHERE published_at IS NOT NULL;
+SELECT setval('outbox_publish_seq', COALESCE((SELECT max(id) FROM outbox), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_published_seq_idx ON outbox (published_seq);
\ No newline at end of file